@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdAgenda() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "agenda [@spec]",
+		Short:   "Show day notes, due tasks and notes due or to be reminded of, per day",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"cal"},
+	}
+
+	var days int
+	cmd.Flags().IntVarP(&days, "days", "d", 7, "Number of days to show")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		from := time.Now()
+		if len(args) == 1 {
+			spec := strings.TrimPrefix(strings.TrimSpace(args[0]), "@")
+			kind, t, err := note.ParsePeriodSpec(spec)
+			if err != nil {
+				exitErr("❓ Sorry, '%s' is not a valid day or period: %v", args[0], err)
+			}
+
+			// agenda for a period (e.g., @week) covers the whole period.
+			from = note.PeriodStart(kind, t)
+			if kind != note.PeriodDay && !cmd.Flags().Changed("days") {
+				days = 0
+				for d := from; note.PeriodStart(kind, d).Equal(from); d = d.AddDate(0, 0, 1) {
+					days++
+				}
+			}
+		}
+
+		agenda, err := notes.Agenda(from, days)
+		if err != nil {
+			exitErr("❗️ %v", err)
+		}
+
+		writeOut(cmd, agenda, func(_ string) string {
+			today := time.Now().Format("2006-01-02")
+
+			var sb strings.Builder
+			for _, day := range agenda {
+				sb.WriteString("📅 " + day.Date.Format("Mon, 02 Jan 2006"))
+				if day.Date.Format("2006-01-02") == today {
+					sb.WriteString(" (today)")
+				}
+				sb.WriteString("\n")
+
+				empty := true
+				if day.DayNote != "" {
+					sb.WriteString(fmt.Sprintf("   📓 %s\n", day.DayNote))
+					empty = false
+				}
+				for _, t := range day.Tasks {
+					done := "☐"
+					if t.Done {
+						done = "☑"
+					}
+					sb.WriteString(fmt.Sprintf("   %s %s (%s:%d)\n", done, t.Text, t.Note, t.Line))
+					empty = false
+				}
+				for _, name := range day.Due {
+					sb.WriteString(fmt.Sprintf("   ⏰ %s is due\n", name))
+					empty = false
+				}
+				for _, name := range day.Remind {
+					sb.WriteString(fmt.Sprintf("   🔔 %s\n", name))
+					empty = false
+				}
+				if empty {
+					sb.WriteString("   -\n")
+				}
+				sb.WriteString("\n")
+			}
+			return strings.TrimSpace(sb.String())
+		})
+	}
+	return cmd
+}
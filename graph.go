@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdGraph() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph [root]",
+		Short: "Export graph of notes connected by links (dot, graphml, json or yaml)",
+		Long: "Export graph of notes connected by [[links]], markdown links and optionally\n" +
+			"shared tags. Use '-o dot' (default), '-o graphml', '-o json' or '-o yaml'.",
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	var opts note.GraphOptions
+	var query string
+	flags := cmd.Flags()
+	flags.StringVarP(&query, "query", "q", "", "Only include notes matching the query (see search)")
+	flags.StringVarP(&opts.Query.Notebook, "notebook", "n", "", "Only include notes in this notebook")
+	flags.IntVarP(&opts.Depth, "depth", "d", 0, "Maximum distance from the root note (0 for no limit)")
+	flags.BoolVar(&opts.SharedTags, "tags", false, "Connect notes that share a tag")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			opts.Root = inferName(args)[0]
+		}
+		opts.Query.Expr = parseQuery(query)
+
+		g, err := notes.Graph(opts)
+		if err != nil {
+			exitErr("❗️ %s", err)
+		}
+
+		writeOut(cmd, g, func(format string) string {
+			var sb strings.Builder
+			if format == "graphml" {
+				err = g.WriteGraphML(&sb)
+			} else {
+				err = g.WriteDOT(&sb)
+			}
+			if err != nil {
+				exitErr("❗️ Failed to write graph: %v", err)
+			}
+			return strings.TrimSpace(sb.String())
+		})
+	}
+	return cmd
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func cmdHistory() *cobra.Command {
+	return &cobra.Command{
+		Use:     "history [name]",
+		Short:   "List prior revisions of a note",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"revs", "log"},
+		Run: func(cmd *cobra.Command, args []string) {
+			args = inferName(args)
+
+			revs, err := notes.History(args[0])
+			if err != nil {
+				exitErr("❗️ Failed to list history: %v", err)
+			}
+
+			writeOut(cmd, revs, func(_ string) string {
+				if len(revs) == 0 {
+					return fmt.Sprintf("❕ No prior revisions of '%s'.", args[0])
+				}
+
+				res := strings.Builder{}
+				table := tablewriter.NewWriter(&res)
+				table.SetHeader([]string{"Rev", "Saved On", "Size"})
+				for _, rev := range revs {
+					table.Append([]string{
+						strconv.Itoa(rev.ID),
+						rev.SavedAt.Format("2006-01-02 15:04:05"),
+						strconv.FormatInt(rev.Size, 10),
+					})
+				}
+				table.Render()
+
+				return strings.TrimSpace(res.String())
+			})
+		},
+	}
+}
+
+func cmdDiff() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <name> [rev1] [rev2]",
+		Short: "Show changes of a note between revisions (defaults to latest revision and current)",
+		Args:  cobra.RangeArgs(1, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := inferName(args[:1])[0]
+
+			from, to := -1, 0
+			if len(args) > 1 {
+				from = parseRev(args[1])
+			}
+			if len(args) > 2 {
+				to = parseRev(args[2])
+			}
+
+			if from == -1 {
+				revs, err := notes.History(name)
+				if err != nil {
+					exitErr("❗️ Failed to list history: %v", err)
+				} else if len(revs) == 0 {
+					exitOk("❕ No prior revisions of '%s'.", name)
+				}
+				from = revs[len(revs)-1].ID
+			}
+
+			diff, err := notes.Diff(name, from, to)
+			if err != nil {
+				exitErr("❗️ Diff failed: %v", err)
+			}
+
+			writeOut(cmd, map[string]interface{}{"name": name, "from": from, "to": to, "diff": diff}, func(_ string) string {
+				if diff == "" {
+					return "❕ No changes."
+				}
+				return strings.TrimSpace(diff)
+			})
+		},
+	}
+}
+
+func cmdRevert() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revert <name> <rev>",
+		Short: "Restore a note to a prior revision",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := inferName(args[:1])[0]
+			rev := parseRev(args[1])
+
+			nt, err := notes.Revert(name, rev)
+			if err != nil {
+				exitErr("❗️ Revert failed: %v", err)
+			}
+
+			writeOut(cmd, nt, func(_ string) string {
+				return fmt.Sprintf("✅ Note '%s' reverted to revision %d", nt.Name, rev)
+			})
+		},
+	}
+}
+
+// parseRev parses a revision id. 'current' (or 'cur') refers to the current
+// version of the note and is represented as 0.
+func parseRev(s string) int {
+	s = strings.TrimSpace(s)
+	if s == "current" || s == "cur" {
+		return 0
+	}
+
+	rev, err := strconv.Atoi(s)
+	if err != nil || rev < 0 {
+		exitErr("❓ Sorry, '%s' is not a valid revision", s)
+	}
+	return rev
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func cmdLinks() *cobra.Command {
+	return &cobra.Command{
+		Use:     "links [name]",
+		Short:   "List notes and attachments linked from a note",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"outlinks"},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := inferName(args)[0]
+
+			links, err := notes.Links(name)
+			if err != nil {
+				exitErr("❗️ %s", err)
+			}
+
+			writeOut(cmd, links, func(_ string) string {
+				if len(links) == 0 {
+					return fmt.Sprintf("❕ '%s' has no links.", name)
+				}
+
+				res := strings.Builder{}
+				table := tablewriter.NewWriter(&res)
+				table.SetHeader([]string{"Line", "Name", "Label", "Kind", "Exists"})
+				for _, l := range links {
+					exists := "✅"
+					if !l.Exists {
+						exists = "❌"
+					}
+					table.Append([]string{fmt.Sprintf("%d", l.Line), l.Name, l.Label, l.Kind, exists})
+				}
+				table.Render()
+
+				return strings.TrimSpace(res.String())
+			})
+		},
+	}
+}
+
+func cmdBacklinks() *cobra.Command {
+	return &cobra.Command{
+		Use:     "backlinks [name]",
+		Short:   "List notes that link to a note",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"inlinks", "refs"},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := inferName(args)[0]
+
+			names, err := notes.Backlinks(name)
+			if err != nil {
+				exitErr("❗️ %s", err)
+			}
+
+			writeOut(cmd, names, func(_ string) string {
+				if len(names) == 0 {
+					return fmt.Sprintf("❕ No notes link to '%s'.", name)
+				}
+				return "🔗 " + strings.Join(names, "\n🔗 ")
+			})
+		},
+	}
+}
+
+func cmdCheckLinks() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "check-links",
+		Short:   "Report broken links (and orphan notes with --orphans), exits with 1 if any",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"lint"},
+	}
+
+	var orphans bool
+	cmd.Flags().BoolVar(&orphans, "orphans", false, "Also report notes that no other note links to")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		report, err := notes.CheckLinks()
+		if err != nil {
+			exitErr("❗️ Check failed: %v", err)
+		}
+		if !orphans {
+			report.Orphans = []string{}
+		}
+
+		writeOut(cmd, report, func(_ string) string {
+			if len(report.Broken) == 0 && len(report.Orphans) == 0 {
+				if !orphans {
+					return "✅ No broken links."
+				}
+				return "✅ No broken links or orphan notes."
+			}
+
+			res := strings.Builder{}
+			if len(report.Broken) > 0 {
+				table := tablewriter.NewWriter(&res)
+				table.SetHeader([]string{"Note", "Line", "Broken Link", "Kind"})
+				for _, b := range report.Broken {
+					table.Append([]string{b.Note, fmt.Sprintf("%d", b.Line), b.Name, b.Kind})
+				}
+				table.Render()
+			}
+
+			if len(report.Orphans) > 0 {
+				res.WriteString("\n🏝  Orphan notes (no inbound links):\n")
+				for _, name := range report.Orphans {
+					res.WriteString("   " + name + "\n")
+				}
+			}
+			return strings.TrimSpace(res.String())
+		})
+
+		if len(report.Broken) > 0 || len(report.Orphans) > 0 {
+			exitErr("❗️ Found %d broken link(s) and %d orphan note(s)", len(report.Broken), len(report.Orphans))
+		}
+	}
+	return cmd
+}
@@ -42,11 +42,13 @@ func main() {
 
 func runCLI(ctx context.Context) {
 	var logLevel, profile string
+	var lockTimeout time.Duration
 	flags := rootCmd.PersistentFlags()
 	flags.StringVarP(&profile, "profile", "p", "work", "Profile to load and use")
 	flags.StringVarP(&logLevel, "log-level", "l", "warn", "Log level to use")
 	flags.StringP("output", "o", "pretty", "Output format (json, yaml, markdown & pretty)")
 	flags.StringP("config", "c", "", "override configuration file")
+	flags.DurationVar(&lockTimeout, "lock-timeout", note.DefaultLockTimeout, "Time to wait for other connote processes using the profile")
 
 	rootCmd.Version = fmt.Sprintf("Version %s (commit %s built on %s)", Version, Commit, BuildTime)
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
@@ -71,7 +73,18 @@ func runCLI(ctx context.Context) {
 		}
 		notesDir := filepath.Join(configDir, profile)
 
-		notes, err = note.Open(profile, notesDir, true, nil)
+		store, err := note.NewDirStore(notesDir, true)
+		if err != nil {
+			return err
+		}
+		store.LockTimeout = lockTimeout
+
+		var periodFormats map[string]string
+		if err := config.Unmarshal("period_formats", &periodFormats); err != nil {
+			return err
+		}
+
+		notes, err = note.Open(profile, store, logrusLog, note.WithPeriodFormats(periodFormats))
 		if err != nil {
 			return err
 		}
@@ -82,14 +95,30 @@ func runCLI(ctx context.Context) {
 	rootCmd.AddCommand(
 		cmdShowNote(),
 		cmdReindex(),
+		cmdWatch(),
 		cmdEditNote(),
+		cmdTemplates(),
 		cmdSearch(),
+		cmdTree(),
+		cmdTags(),
+		cmdTasks(),
+		cmdAgenda(),
+		cmdPeriod(),
+		cmdLinks(),
+		cmdBacklinks(),
+		cmdCheckLinks(),
+		cmdGraph(),
 		cmdLoadNotes(),
+		cmdMoveNote(),
 		cmdRemoveNote(),
+		cmdTrash(),
+		cmdHistory(),
+		cmdDiff(),
+		cmdRevert(),
 		cmdInfo(),
 	)
 
-	_ = rootCmd.Execute()
+	_ = rootCmd.ExecuteContext(ctx)
 }
 
 func cmdInfo() *cobra.Command {
@@ -114,3 +143,11 @@ func cmdInfo() *cobra.Command {
 		},
 	}
 }
+
+func logrusLog(lvl, format string, args ...interface{}) {
+	level, err := logrus.ParseLevel(lvl)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	logrus.StandardLogger().Logf(level, format, args...)
+}
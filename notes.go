@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/spy16/connote/pkg/config"
 	"github.com/spy16/connote/pkg/note"
 )
 
@@ -32,20 +34,27 @@ func cmdEditNote() *cobra.Command {
 	}
 
 	var tags []string
+	var tmplName, rollover string
+	var markMigrated bool
 	flags := cmd.Flags()
 	flags.StringSliceVarP(&tags, "tags", "t", nil, "Tags to categorize")
+	flags.StringVarP(&tmplName, "template", "T", "", "Template to create the note from (see templates)")
+	flags.StringVar(&rollover, "rollover", "ask", "Carry over unchecked items from previous day note to a new day note (ask, always or never)")
+	flags.BoolVar(&markMigrated, "mark-migrated", false, "Mark carried over items as migrated ('- [>]') in the previous day note")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if rollover != "ask" && rollover != "always" && rollover != "never" {
+			exitErr("❓ --rollover must be one of ask, always or never, not '%s'", rollover)
+		}
 		args = inferName(args)
 
 		var nt note.Note
 		existing, err := notes.Get(args[0])
 		if err != nil {
 			if errors.Is(err, note.ErrNotFound) {
-				nt.Name = strings.TrimSpace(args[0])
-				nt.Content = "# " + nt.Name
+				nt = newNote(strings.TrimSpace(args[0]), tmplName)
 
-				newAr, err := notes.Put(nt, true)
+				newAr, err := createNote(nt, rollover, markMigrated)
 				if err != nil {
 					exitErr("❗️ failed to create: %v", err)
 				}
@@ -75,6 +84,96 @@ func cmdEditNote() *cobra.Command {
 	return cmd
 }
 
+// newNote returns a new note created from the template. If no template is
+// given, template is selected by the configured name patterns (or the kind,
+// e.g. 'week', for periodic notes). Only the default template of the kind
+// may not exist, in which case the note has just a heading.
+func newNote(name, tmplName string) note.Note {
+	explicit := tmplName != ""
+	if !explicit {
+		tmplName, explicit = templateFor(name)
+	}
+
+	if tmplName != "" {
+		nt, err := notes.FromTemplate(name, tmplName, time.Now())
+		if err == nil {
+			return *nt
+		} else if explicit || !errors.Is(err, note.ErrNotFound) {
+			exitErr("❗️ failed to create from template: %v", err)
+		}
+	}
+	return note.Note{Name: name, Content: "# " + name}
+}
+
+// createNote saves the new note. If it is a day note for today or later,
+// unchecked items in the previous day note are carried over as per the
+// rollover mode (ask, always or never).
+func createNote(nt note.Note, rollover string, markMigrated bool) (*note.Note, error) {
+	today := note.PeriodStart(note.PeriodDay, time.Now())
+
+	day, isDay := notes.ParseDayNoteName(nt.Name)
+	if !isDay || rollover == "never" || day.Before(today) {
+		return notes.Put(nt, true)
+	}
+
+	prev, found := notes.PrevDayNote(day)
+	if !found {
+		return notes.Put(nt, true)
+	}
+
+	items, err := notes.UncheckedItems(prev)
+	if err != nil {
+		return nil, err
+	} else if len(items) == 0 {
+		return notes.Put(nt, true)
+	}
+
+	if rollover == "ask" && !confirm("📋 Carry over %d unchecked item(s) from '%s'? [y/N]: ", len(items), prev) {
+		return notes.Put(nt, true)
+	}
+
+	created, count, err := notes.Rollover(nt, prev, markMigrated)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("carried over %d item(s) from '%s'", count, prev)
+	return created, nil
+}
+
+// templateFor returns the template for notes with the given name and true
+// if it is configured, false for the default template of periodic notes.
+// Templates are configured as a list of name patterns and templates, e.g.:
+//
+//	templates:
+//	  - pattern: ^meeting/
+//	    template: meeting
+func templateFor(name string) (string, bool) {
+	var rules []struct {
+		Pattern  string `mapstructure:"pattern"`
+		Template string `mapstructure:"template"`
+	}
+	if err := config.Unmarshal("templates", &rules); err != nil {
+		exitErr("❗️ invalid templates config: %v", err)
+	}
+
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			exitErr("❗️ invalid template pattern '%s': %v", rule.Pattern, err)
+		} else if re.MatchString(name) {
+			return rule.Template, true
+		}
+	}
+
+	if kind, _, ok := notes.ParsePeriodNoteName(name); ok {
+		if tmplName := config.String(kind + "_template"); tmplName != "" {
+			return tmplName, true
+		}
+		return kind, false
+	}
+	return "", false
+}
+
 func cmdShowNote() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "show [id-or-name]",
@@ -130,6 +229,13 @@ func cmdShowNote() *cobra.Command {
 			if err != nil {
 				exitErr("❗ render failed: %v", err)
 			}
+
+			if backlinks, err := notes.Backlinks(nt.Name); err == nil && len(backlinks) > 0 {
+				md += fmt.Sprintf("  🔗 Linked from: %s\n", strings.Join(backlinks, ", "))
+			}
+			if nav, err := notes.PeriodNav(nt.Name); err == nil {
+				md += renderPeriodNav(nav)
+			}
 			return md
 		}
 
@@ -139,16 +245,38 @@ func cmdShowNote() *cobra.Command {
 }
 
 func cmdReindex() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:     "reindex",
-		Short:   "Force re-index current profile directory",
+		Short:   "Re-index notes added or modified in current profile directory",
 		Aliases: []string{"ri", "idx"},
+	}
+
+	var full bool
+	cmd.Flags().BoolVar(&full, "full", false, "Re-read all notes instead of only the modified ones")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		err := notes.Index(full)
+		if err != nil {
+			exitErr("💣 %s", err)
+		}
+		exitOk("✅ Successfully re-indexed")
+	}
+	return cmd
+}
+
+func cmdWatch() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the index in sync with changes made in the profile directory",
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := notes.Index()
-			if err != nil {
+			profile, dir, _ := notes.Stats()
+			_, _ = fmt.Fprintf(os.Stderr, "👀 Watching '%s' (%s) for changes, press Ctrl+C to stop\n", profile, dir)
+
+			if err := notes.Watch(cmd.Context()); err != nil {
 				exitErr("💣 %s", err)
 			}
-			exitOk("✅ Successfully re-indexed")
+			exitOk("✅ Stopped watching")
 		},
 	}
 }
@@ -162,44 +290,55 @@ func cmdSearch() *cobra.Command {
 	}
 
 	var q note.Query
-	var after, before string
+	var after, before, modSince, modBefore, query string
 	var loadFull bool
+	var page int
+	var where []string
 	flags := cmd.Flags()
 	flags.BoolVar(&loadFull, "full", false, "Load note from file instead of partial data from index")
 	flags.StringVarP(&after, "after", "a", "", "Created After")
 	flags.StringVarP(&before, "before", "b", "", "Created Before")
+	flags.StringVar(&modSince, "modified-since", "", "Updated after this time (e.g., yday, -7, 7d, 02/01/2006)")
+	flags.StringVar(&modBefore, "modified-before", "", "Updated before this time")
+	flags.StringVarP(&q.Notebook, "notebook", "n", "", "Only notes in this notebook (e.g., team/oncall)")
+	flags.StringVar(&q.Text, "text", "", "Match words or \"quoted phrases\" in note content")
+	flags.StringVarP(&query, "query", "q", "", "Query (e.g., 'tag:oncall -tag:draft updated:<7d \"consumer lag\"')")
+	flags.StringVar(&q.Sort, "sort", note.SortCreated, "Sort by one of created, updated, name or relevance")
+	flags.BoolVar(&q.Reverse, "reverse", false, "Reverse the sort order")
+	flags.IntVar(&q.Limit, "limit", 0, "Maximum number of notes to list (0 for no limit)")
+	flags.IntVar(&page, "page", 1, "Page of results to list (requires --limit)")
 	flags.StringSliceVarP(&q.IncludeTags, "include", "i", nil, "Include notes with this tag")
 	flags.StringSliceVarP(&q.ExcludeTags, "exclude", "e", nil, "Exclude notes with this tag")
+	flags.StringArrayVarP(&where, "where", "w", nil, "Filter by key:value tags (e.g., 'project=*', 'priority>=2', 'env in (prod,stg)')")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		if len(args) == 1 {
 			q.NameLike = strings.TrimSpace(args[0])
 		}
 
-		after = strings.TrimSpace(after)
-		before = strings.TrimSpace(before)
-		if after != "" {
-			afterT, err := note.ParseTime(after)
+		for _, w := range where {
+			f, err := note.ParseTagFilter(w)
 			if err != nil {
-				exitErr("❓ Sorry, '%s' is not valid time-string: %v", after, err)
+				exitErr("❓ %v", err)
 			}
+			q.TagFilters = append(q.TagFilters, f)
+		}
 
-			if after == before {
-				dayStart := time.Date(afterT.Year(), afterT.Month(), afterT.Day(), 0, 0, 0, 0, afterT.Location())
-				dayEnd := time.Date(afterT.Year(), afterT.Month(), afterT.Day(), 23, 59, 59, 0, afterT.Location())
-				q.CreatedRange = [2]int64{dayStart.Unix(), dayEnd.Unix()}
-			} else {
-				q.CreatedRange = [2]int64{afterT.Unix(), time.Now().Unix()}
+		q.Expr = parseQuery(query)
+
+		q.CreatedRange = parseRange(after, before)
+		q.UpdatedRange = parseRange(modSince, modBefore)
+		if page > 1 {
+			if q.Limit <= 0 {
+				exitErr("❓ --page requires --limit to be set")
 			}
+			q.Offset = (page - 1) * q.Limit
 		}
 
 		notesList, err := notes.Search(q, false)
 		if err != nil {
 			exitErr("❗️Search failed: %v", err)
 		}
-		if notesList == nil {
-			notesList = []note.Note{}
-		}
 
 		writeOut(cmd, notesList, func(format string) string {
 			if len(notesList) == 0 {
@@ -208,13 +347,23 @@ func cmdSearch() *cobra.Command {
 
 			res := strings.Builder{}
 			table := tablewriter.NewWriter(&res)
-			table.SetHeader([]string{"Name", "Tags", "Created On"})
+			header := []string{"Name", "Tags", "Created On", "Updated On"}
+			ranked := q.Sort == note.SortRelevance
+			if ranked {
+				header = append(header, "Score", "Snippet")
+			}
+			table.SetHeader(header)
 			for _, s := range notesList {
 				tags := "-"
 				if len(s.Tags) > 0 {
 					tags = strings.Join(s.Tags, ", ")
 				}
-				table.Append([]string{s.Name, tags, s.CreatedAt.Format("2006-01-02")})
+
+				row := []string{s.Name, tags, s.CreatedAt.Format("2006-01-02"), s.UpdatedAt.Format("2006-01-02")}
+				if ranked {
+					row = append(row, fmt.Sprintf("%.2f", s.Score), s.Snippet)
+				}
+				table.Append(row)
 			}
 			table.Render()
 
@@ -224,6 +373,62 @@ func cmdSearch() *cobra.Command {
 	return cmd
 }
 
+// parseQuery parses the query string and returns its expression. Exits with
+// the position of the error if the query is invalid.
+func parseQuery(query string) note.Expr {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	parsed, err := note.ParseQuery(query)
+	if err != nil {
+		var syntaxErr *note.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			exitErr("❓ Invalid query: %s\n\n    %s\n    %s^", syntaxErr.Msg, query,
+				strings.Repeat(" ", syntaxErr.Pos-1))
+		}
+		exitErr("❓ Invalid query: %v", err)
+	}
+	return parsed.Expr
+}
+
+// parseRange parses the time-strings into a range of unix timestamps. If both
+// are same, the range covers that entire day.
+func parseRange(after, before string) [2]int64 {
+	var r [2]int64
+
+	after = strings.TrimSpace(after)
+	before = strings.TrimSpace(before)
+	if after != "" {
+		afterT := parseTimeSpec(after)
+		if after == before {
+			dayStart := time.Date(afterT.Year(), afterT.Month(), afterT.Day(), 0, 0, 0, 0, afterT.Location())
+			dayEnd := time.Date(afterT.Year(), afterT.Month(), afterT.Day(), 23, 59, 59, 0, afterT.Location())
+			return [2]int64{dayStart.Unix(), dayEnd.Unix()}
+		}
+		r[0] = afterT.Unix()
+	}
+
+	if before != "" {
+		r[1] = parseTimeSpec(before).Unix()
+	}
+	return r
+}
+
+// parseTimeSpec parses a time-string supported by note.ParseTime or a
+// duration (e.g., 7d) relative to now.
+func parseTimeSpec(spec string) time.Time {
+	t, err := note.ParseTime(spec)
+	if err != nil {
+		d, durErr := note.ParseDuration(spec)
+		if durErr != nil {
+			exitErr("❓ Sorry, '%s' is not valid time-string: %v", spec, err)
+		}
+		t = time.Now().Add(-d)
+	}
+	return t
+}
+
 func cmdLoadNotes() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "from <dir-or-file>",
@@ -294,6 +499,37 @@ func cmdLoadNotes() *cobra.Command {
 	return cmd
 }
 
+func cmdMoveNote() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "mv <old> <new>",
+		Short:   "Rename a note and update links to it in other notes",
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"rename", "move"},
+	}
+
+	var dryRun bool
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the notes that would be updated")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		from := inferName(args[:1])[0]
+		to := strings.TrimSpace(args[1])
+
+		updated, err := notes.Rename(from, to, dryRun)
+		if err != nil {
+			exitErr("❗️ Rename failed: %v", err)
+		}
+
+		if dryRun {
+			if len(updated) == 0 {
+				exitOk("❕ '%s' can be renamed to '%s', no other notes link to it", from, to)
+			}
+			exitOk("❕ Renaming '%s' to '%s' would update links in:\n   %s", from, to, strings.Join(updated, "\n   "))
+		}
+		exitOk("✅ Note '%s' has been renamed to '%s', updated links in %d note(s)", from, to, len(updated))
+	}
+	return cmd
+}
+
 func cmdRemoveNote() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "rm <name>",
@@ -327,7 +563,7 @@ func cmdRemoveNote() *cobra.Command {
 			if err := notes.Del(name); err != nil {
 				exitErr("❗️Deletion failed: %v", err)
 			} else {
-				exitOk("✅ Note '%s' has been deleted", name)
+				exitOk("✅ Note '%s' has been moved to trash", name)
 			}
 		}
 	}
@@ -339,13 +575,13 @@ func inferName(args []string) []string {
 	const expander = "@"
 
 	if len(args) == 0 {
-		return []string{makeDayID(time.Now())}
+		return []string{notes.DayNoteName(time.Now())}
 	} else if strings.HasPrefix(args[0], expander) {
 		spec := strings.TrimPrefix(args[0], expander)
 
-		t, err := note.ParseTime(spec)
+		kind, t, err := note.ParsePeriodSpec(spec)
 		if err == nil {
-			return []string{makeDayID(t)}
+			return []string{notes.PeriodNoteName(kind, t)}
 		}
 	}
 
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdPeriod() *cobra.Command {
+	return &cobra.Command{
+		Use:     "period [@spec|name]",
+		Short:   "Show notes related to a periodic note (e.g., day notes of @week)",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"nav"},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := inferName(args)[0]
+
+			nav, err := notes.PeriodNav(name)
+			if err != nil {
+				exitErr("❗️ %v", err)
+			}
+
+			writeOut(cmd, nav, func(_ string) string {
+				header := fmt.Sprintf("📆 %s (%s to %s)\n", nav.Name,
+					nav.Start.Format("2006-01-02"), nav.End.AddDate(0, 0, -1).Format("2006-01-02"))
+				return strings.TrimRight(header+renderPeriodNav(nav), "\n")
+			})
+		},
+	}
+}
+
+func renderPeriodNav(nav *note.PeriodNav) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  ⬅️  %s | %s ➡️\n", nav.Prev, nav.Next))
+	if len(nav.Parents) > 0 {
+		sb.WriteString(fmt.Sprintf("  📆 Part of: %s\n", strings.Join(nav.Parents, ", ")))
+	}
+	if len(nav.Days) > 0 {
+		sb.WriteString(fmt.Sprintf("  📓 Days: %s\n", strings.Join(nav.Days, ", ")))
+	}
+	return sb.String()
+}
@@ -71,3 +71,12 @@ func viperInit(envPrefix, configName string, configFile ...string) error {
 	viper.AutomaticEnv()
 	return nil
 }
+
+// Unmarshal decodes the value set for the given key into v (e.g., a slice
+// of structs). v is left unchanged if the key is not set.
+func Unmarshal(key string, v interface{}) error {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	return viper.UnmarshalKey(key, v)
+}
@@ -0,0 +1,85 @@
+package note
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// AgendaDay has the day note, tasks due and notes due or to be reminded of
+// on a day.
+type AgendaDay struct {
+	Date    time.Time `json:"date" yaml:"date"`
+	DayNote string    `json:"day_note,omitempty" yaml:"day_note,omitempty"`
+	Tasks   []Task    `json:"tasks,omitempty" yaml:"tasks,omitempty"`
+	Due     []string  `json:"due,omitempty" yaml:"due,omitempty"`
+	Remind  []string  `json:"remind,omitempty" yaml:"remind,omitempty"`
+}
+
+// Agenda returns the agenda for the given number of days starting from the
+// day of the given time. Every day in the range is included, even if there
+// is nothing on it.
+func (api *API) Agenda(from time.Time, days int) ([]AgendaDay, error) {
+	if days <= 0 {
+		return nil, errors.New("number of days must be positive")
+	}
+
+	start := PeriodStart(PeriodDay, from)
+	agenda := make([]AgendaDay, days)
+	byDay := map[string]*AgendaDay{}
+	for i := range agenda {
+		agenda[i].Date = start.AddDate(0, 0, i)
+		byDay[agenda[i].Date.Format("2006-01-02")] = &agenda[i]
+	}
+	dayOf := func(ts int64) *AgendaDay {
+		if ts == 0 {
+			return nil
+		}
+		return byDay[time.Unix(ts, 0).Format("2006-01-02")]
+	}
+
+	for name, node := range api.idx {
+		if d, ok := api.ParseDayNoteName(name); ok {
+			if day := dayOf(d.Unix()); day != nil {
+				day.DayNote = name
+			}
+		}
+		if day := dayOf(node.Due); day != nil {
+			day.Due = append(day.Due, name)
+		}
+		if day := dayOf(node.Remind); day != nil {
+			day.Remind = append(day.Remind, name)
+		}
+		for _, it := range node.Tasks {
+			if day := dayOf(it.Due); day != nil {
+				day.Tasks = append(day.Tasks, it.toTask(name))
+			}
+		}
+	}
+
+	for i := range agenda {
+		day := &agenda[i]
+		sort.Strings(day.Due)
+		sort.Strings(day.Remind)
+		sort.Slice(day.Tasks, func(a, b int) bool {
+			if day.Tasks[a].Note != day.Tasks[b].Note {
+				return day.Tasks[a].Note < day.Tasks[b].Note
+			}
+			return day.Tasks[a].Line < day.Tasks[b].Line
+		})
+	}
+	return agenda, nil
+}
+
+// dateUnix returns the unix timestamp of the front-matter date. Dates
+// without time (e.g., 'due: 2006-01-02') are decoded as midnight UTC and
+// are taken as the start of that day in local time.
+func dateUnix(t *time.Time) int64 {
+	if t == nil || t.IsZero() {
+		return 0
+	}
+	if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+		return PeriodStart(PeriodDay, *t).Unix()
+	}
+	return t.Unix()
+}
@@ -0,0 +1,68 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPI_Agenda(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	start := time.Date(2021, 3, 1, 15, 0, 0, 0, time.Local)
+	day2 := api.DayNoteName(start.AddDate(0, 0, 1))
+
+	docs := []string{
+		"---\nname: " + day2 + "\n---\n- [ ] standup due:2021-03-01\n- [x] deploy due:2021-03-03",
+		"---\nname: report\ndue: 2021-03-02\nremind: 2021-03-01\n---\n# Report",
+		"---\nname: later\ndue: 2021-03-10\n---\n# Later",
+	}
+	for _, doc := range docs {
+		n, err := Parse([]byte(doc))
+		if err != nil {
+			t.Fatalf("Parse() unexpected error: %v", err)
+		}
+		if _, err := api.Put(*n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	agenda, err := api.Agenda(start, 3)
+	if err != nil {
+		t.Fatalf("Agenda() unexpected error: %v", err)
+	} else if len(agenda) != 3 {
+		t.Fatalf("Agenda() got %d days, want 3", len(agenda))
+	}
+
+	type summary struct {
+		Date    string
+		DayNote string
+		Tasks   []string
+		Due     []string
+		Remind  []string
+	}
+	var got []summary
+	for _, day := range agenda {
+		s := summary{Date: day.Date.Format("2006-01-02"), DayNote: day.DayNote, Due: day.Due, Remind: day.Remind}
+		for _, task := range day.Tasks {
+			s.Tasks = append(s.Tasks, task.Text)
+		}
+		got = append(got, s)
+	}
+
+	want := []summary{
+		{Date: "2021-03-01", Tasks: []string{"standup due:2021-03-01"}, Remind: []string{"report"}},
+		{Date: "2021-03-02", DayNote: day2, Due: []string{"report"}},
+		{Date: "2021-03-03", Tasks: []string{"deploy due:2021-03-03"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Agenda() got %+v, want %+v", got, want)
+	}
+
+	if _, err := api.Agenda(start, 0); err == nil {
+		t.Errorf("Agenda() expected error for zero days")
+	}
+}
@@ -4,27 +4,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"log"
-	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 )
 
-const idxName = "notes_idx.json"
+const (
+	idxName    = "notes_idx.json"
+	idxVersion = 9
+)
 
 var (
 	ErrNotFound = errors.New("not found")
 	ErrConflict = errors.New("conflict")
+	ErrBusy     = errors.New("busy")
 )
 
-// Open returns a new API instance for given directory. If directory is not found
-// it will be created automatically.
-func Open(profileName, dir string, init bool, logFn LogFn) (*API, error) {
+// Open returns a new API instance backed by the given store. Index is loaded
+// from the store and is refreshed with notes added or modified in the store
+// directly. Index is re-built if not found.
+func Open(profileName string, store Store, logFn LogFn, opts ...Option) (*API, error) {
 	if logFn == nil {
 		logFn = func(lvl, format string, args ...interface{}) {
 			lvl = strings.ToUpper(lvl)
@@ -32,32 +33,53 @@ func Open(profileName, dir string, init bool, logFn LogFn) (*API, error) {
 		}
 	}
 
-	info, err := os.Stat(dir)
-	if err != nil && !os.IsNotExist(err) {
+	api := &API{
+		store:         store,
+		log:           logFn,
+		profile:       profileName,
+		periodFormats: copyFormats(DefaultPeriodFormats),
+	}
+	for _, opt := range opts {
+		if err := opt(api); err != nil {
+			return nil, err
+		}
+	}
+
+	unlock, err := api.lock()
+	if err != nil {
 		return nil, err
-	} else if os.IsNotExist(err) && !init {
-		return nil, fmt.Errorf("profile directory non-existent")
-	} else if !info.IsDir() {
-		return nil, fmt.Errorf("profile path is not a directory")
 	}
+	defer unlock()
 
-	api := &API{dir: dir, log: logFn, profile: profileName}
-	if err := api.initDir(); err != nil {
+	if err := api.loadIdx(); err != nil {
+		return nil, err
+	} else if err := api.recoverJournal(); err != nil {
 		return nil, err
 	}
-	return api, api.loadIdx()
+	return api, api.refresh(false, false)
 }
 
-// API provides functions to manage notes in a given directory.
+// Option configures an API instance in Open.
+type Option func(api *API) error
+
+// API provides functions to manage notes in a given store.
 type API struct {
-	dir     string
+	store   Store
 	log     LogFn
 	idx     map[string]indexNode
+	terms   map[string]map[string]struct{}
 	profile string
+
+	// backlinks maps name of a note to the names of notes linking to it.
+	backlinks map[string]map[string]struct{}
+
+	// periodFormats maps kinds of periodic notes to formats of their names.
+	periodFormats map[string]string
 }
 
-// Search finds names of all notes that match the given query.
-func (api *API) Search(q Query, loadNote bool) ([]Note, error) {
+// Search finds all notes that match the given query. Hits are sorted as per
+// the query and have a score and a snippet only when sorted by relevance.
+func (api *API) Search(q Query, loadNote bool) ([]Hit, error) {
 	var nameRE *regexp.Regexp
 	q.NameLike = strings.TrimSpace(q.NameLike)
 	if q.NameLike != "" {
@@ -68,211 +90,533 @@ func (api *API) Search(q Query, loadNote bool) ([]Note, error) {
 		nameRE = np
 	}
 
-	var res []Note
+	var textMatches map[string]struct{}
+	if phrases := parseText(q.Text); len(phrases) > 0 {
+		textMatches = api.textMatches(phrases)
+	}
+
+	var names []string
 	for name, node := range api.idx {
 		if nameRE != nil && !nameRE.MatchString(name) {
 			continue
-		} else if !q.isMatch(node) {
+		} else if !q.isMatch(node) || !inNotebook(name, q.Notebook) {
+			continue
+		} else if q.Expr != nil && !q.Expr.match(name, node) {
 			continue
+		} else if textMatches != nil {
+			if _, found := textMatches[name]; !found {
+				continue
+			}
 		}
+		names = append(names, name)
+	}
+
+	var terms []string
+	var scores map[string]float64
+	if q.Sort == SortRelevance {
+		terms = queryTerms(q)
+		scores = api.scoreBM25(terms, names)
+	}
+
+	if err := api.sortNames(names, q.Sort, q.Reverse, scores); err != nil {
+		return nil, err
+	}
+	names = paginate(names, q.Offset, q.Limit)
 
-		if loadNote {
+	res := make([]Hit, 0, len(names))
+	for _, name := range names {
+		node := api.idx[name]
+
+		hit := Hit{Score: scores[name]}
+		if loadNote || len(terms) > 0 {
 			n, err := api.Get(name)
 			if err != nil {
 				return nil, err
 			}
-			res = append(res, *n)
+			hit.Note = *n
+
+			if len(terms) > 0 {
+				hit.Snippet = makeSnippet(n.Content, terms)
+			}
+			if !loadNote {
+				hit.Content = ""
+			}
 		} else {
-			res = append(res, Note{
+			hit.Note = Note{
 				Name:      name,
 				Tags:      setToArray(node.Tags),
 				CreatedAt: time.Unix(node.CreatedAt, 0),
-			})
+				UpdatedAt: time.Unix(node.UpdatedAt, 0),
+			}
 		}
+		res = append(res, hit)
 	}
-
-	sort.Slice(res, func(i, j int) bool {
-		return res[i].CreatedAt.After(res[j].CreatedAt)
-	})
 	return res, nil
 }
 
 // Get returns a note by its unique name.
 func (api *API) Get(name string) (*Note, error) {
 	name = strings.TrimSpace(name)
-	if _, found := api.idx[name]; !found {
+	node, found := api.idx[name]
+	if !found {
 		return nil, fmt.Errorf("%w: note with name '%s'", ErrNotFound, name)
 	}
 
-	d, err := os.ReadFile(api.getPath(name))
+	d, err := api.store.Get(noteKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := Parse(d)
 	if err != nil {
 		return nil, err
 	}
 
-	return Parse(d)
+	// notes edited by hand may not have name or timestamps in front-matter,
+	// these are filled from the key and the index like in readNode.
+	n.Name = name
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Unix(node.CreatedAt, 0)
+	}
+	if n.UpdatedAt.IsZero() {
+		n.UpdatedAt = time.Unix(node.UpdatedAt, 0)
+	}
+	return n, nil
 }
 
 // Put saves a new note. If a note with same name exists and this is not
-// an update, returns ErrConflict.
+// an update, returns ErrConflict. When updating, creation time of the note
+// is retained unless the note being saved has it set.
 func (api *API) Put(note Note, createOnly bool) (*Note, error) {
+	hasCreatedAt := !note.CreatedAt.IsZero()
 	if err := note.Validate(); err != nil {
 		return nil, err
 	}
-	note.CreatedAt = time.Now()
 	note.UpdatedAt = time.Now()
 
-	if _, found := api.idx[note.Name]; found && createOnly {
-		return nil, fmt.Errorf("%w: note with name '%s' already exists", ErrConflict, note.Name)
+	err := api.apply("put", []string{note.Name}, func() error {
+		if err := api.put(&note, createOnly, hasCreatedAt); err != nil {
+			return err
+		}
+		return api.syncIdx()
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &note, nil
+}
 
-	path := api.getPath(note.Name)
-	if err := ioutil.WriteFile(path, note.ToMarkdown(), 0644); err != nil {
-		return nil, err
+// put saves the validated note and updates its index entry. It must be run
+// as part of an operation (see apply).
+func (api *API) put(note *Note, createOnly, hasCreatedAt bool) error {
+	if existing, found := api.idx[note.Name]; found {
+		if createOnly {
+			return fmt.Errorf("%w: note with name '%s' already exists", ErrConflict, note.Name)
+		} else if err := api.saveRevision(*note); err != nil {
+			return err
+		}
+
+		if !hasCreatedAt {
+			note.CreatedAt = time.Unix(existing.CreatedAt, 0)
+		}
 	}
 
-	api.idx[note.Name] = indexNode{
-		Tags:      arrToSet(note.Tags),
-		CreatedAt: note.CreatedAt.Unix(),
+	if err := api.store.Put(noteKey(note.Name), note.ToMarkdown()); err != nil {
+		return err
 	}
-	return &note, api.syncIdx()
+	return api.putNode(note.Name, newIndexNode(*note))
 }
 
-// Del deletes a note with given name. If not found, returns ErrNotFound.
+// Del moves a note with given name to the trash. If not found, returns
+// ErrNotFound.
 func (api *API) Del(name string) error {
-	path := api.getPath(name)
+	name = strings.TrimSpace(name)
+	return api.apply("del", []string{name}, func() error {
+		if _, found := api.idx[name]; !found {
+			return fmt.Errorf("%w: note with name '%s'", ErrNotFound, name)
+		}
+
+		if err := api.moveToTrash(name); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
 
-	if _, found := api.idx[name]; !found {
-		return fmt.Errorf("%w: note with name '%s'", ErrNotFound, name)
+		api.delNode(name)
+		return api.syncIdx()
+	})
+}
+
+// Index brings the index in sync with the notes in the store. Only the notes
+// added or modified since they were last indexed are read unless full is set.
+func (api *API) Index(full bool) error {
+	unlock, err := api.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	delete(api.idx, name)
-	if err := api.syncIdx(); err != nil {
+	if err := api.loadIdx(); err != nil {
 		return err
 	}
+	return api.refresh(full, true)
+}
+
+// Stats returns statistics of this note storage.
+func (api *API) Stats() (profile, location string, count int) {
+	return api.profile, api.store.String(), len(api.idx)
+}
 
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+func (api *API) loadIdx() error {
+	d, err := api.store.Get(idxName)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return api.refresh(true, false)
+		}
 		return err
 	}
 
+	var idx indexFile
+	if err := json.Unmarshal(d, &idx); err != nil || idx.Version != idxVersion {
+		api.log("info", "index is outdated or corrupt, re-building")
+		return api.refresh(true, false)
+	}
+
+	api.idx = map[string]indexNode{}
+	api.terms = map[string]map[string]struct{}{}
+	api.backlinks = map[string]map[string]struct{}{}
+	for name, node := range idx.Notes {
+		api.setNode(name, node)
+	}
 	return nil
 }
 
-// Index walks the directory and re-builds the index.
-func (api *API) Index() error {
-	api.idx = map[string]indexNode{}
+// refresh updates the index with the notes in the store. Only the notes with
+// size or modification time different from the index are read unless full is
+// set. If strict is not set, notes that fail to be read are skipped with a
+// warning instead of returning error.
+func (api *API) refresh(full, strict bool) error {
+	entries, err := api.store.List("")
+	if err != nil {
+		return err
+	}
 
-	walkErr := filepath.Walk(api.dir, func(path string, info fs.FileInfo, err error) error {
-		if info.IsDir() && api.dir != path {
-			api.log("debug", "skipping dir '%s'", path)
-			return filepath.SkipDir
-		} else if err != nil {
-			return err
-		} else if !strings.HasSuffix(info.Name(), ".md") {
-			return nil
-		}
+	if full || api.idx == nil {
+		api.idx = map[string]indexNode{}
+		api.terms = map[string]map[string]struct{}{}
+		api.backlinks = map[string]map[string]struct{}{}
+	}
 
-		api.log("debug", "reading file '%s'", path)
+	changed := full
+	seen := map[string]struct{}{}
+	for _, entry := range entries {
+		name, ok := nameFromKey(entry.Key)
+		if !ok {
+			continue
+		}
+		seen[name] = struct{}{}
 
-		d, err := os.ReadFile(path)
-		if err != nil {
-			return err
+		node, found := api.idx[name]
+		if found && node.Size == entry.Size && node.ModTime == entry.ModTime.UnixNano() {
+			continue
 		}
 
-		n, err := Parse(d)
+		api.log("debug", "reading '%s'", entry.Key)
+		node, err := api.readNode(entry)
 		if err != nil {
-			return err
+			if strict {
+				return fmt.Errorf("failed to index '%s': %w", entry.Key, err)
+			}
+			api.log("warn", "skipping '%s': %v", entry.Key, err)
+			continue
 		}
+		api.setNode(name, node)
+		changed = true
+	}
 
-		api.idx[n.Name] = indexNode{
-			Tags:      arrToSet(n.Tags),
-			CreatedAt: n.CreatedAt.Unix(),
+	for name := range api.idx {
+		if _, found := seen[name]; !found {
+			api.log("debug", "removing '%s' from index", name)
+			api.delNode(name)
+			changed = true
 		}
-		return nil
-	})
-	if walkErr != nil {
-		api.idx = nil
-		return walkErr
 	}
 
+	if !changed {
+		return nil
+	}
 	return api.syncIdx()
 }
 
-// Stats returns statistics of this note storage.
-func (api *API) Stats() (profile, dir string, count int) {
-	return api.profile, api.dir, len(api.idx)
-}
+// readNode reads the note at the entry and returns the index node for it.
+func (api *API) readNode(entry Entry) (indexNode, error) {
+	d, err := api.store.Get(entry.Key)
+	if err != nil {
+		return indexNode{}, err
+	}
 
-func (api *API) loadIdx() error {
-	idxPath := filepath.Join(api.dir, idxName)
-	if fi, err := os.Stat(idxPath); err != nil {
-		if os.IsNotExist(err) {
-			return api.Index()
-		}
-		return err
-	} else if fi.IsDir() {
-		return fmt.Errorf("'%s' is a directory, not an index file", idxPath)
+	n, err := Parse(d)
+	if err != nil {
+		return indexNode{}, err
+	}
+
+	// notes edited by hand may not have timestamps or may have stale ones.
+	// name is always derived from the key since links are resolved using it.
+	if name, ok := nameFromKey(entry.Key); ok {
+		n.Name = name
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = entry.ModTime
+	}
+	if n.UpdatedAt.Before(entry.ModTime) {
+		n.UpdatedAt = entry.ModTime
 	}
 
-	d, err := os.ReadFile(idxPath)
+	node := newIndexNode(*n)
+	node.Size = entry.Size
+	node.ModTime = entry.ModTime.UnixNano()
+	return node, nil
+}
+
+// putNode adds the node to the index along with the size and modification
+// time of the note in the store.
+func (api *API) putNode(name string, node indexNode) error {
+	entry, err := api.store.Stat(noteKey(name))
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(d, &api.idx)
+
+	node.Size = entry.Size
+	node.ModTime = entry.ModTime.UnixNano()
+	api.setNode(name, node)
+	return nil
 }
 
 func (api *API) syncIdx() error {
-	idxPath := filepath.Join(api.dir, idxName)
-	d, err := json.Marshal(api.idx)
+	d, err := json.Marshal(indexFile{
+		Version: idxVersion,
+		Notes:   api.idx,
+	})
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(idxPath, d, 0644)
+	return api.store.Put(idxName, d)
 }
 
-func (api *API) initDir() error {
-	if err := os.MkdirAll(api.dir, os.ModePerm); err != nil {
-		return err
+func (api *API) setNode(name string, node indexNode) {
+	api.delNode(name)
+
+	api.idx[name] = node
+	for term := range node.Terms {
+		if api.terms[term] == nil {
+			api.terms[term] = map[string]struct{}{}
+		}
+		api.terms[term][name] = struct{}{}
 	}
 
-	return nil
+	for _, target := range node.Links {
+		if api.backlinks[target] == nil {
+			api.backlinks[target] = map[string]struct{}{}
+		}
+		api.backlinks[target][name] = struct{}{}
+	}
 }
 
-func (api *API) getPath(name string) string {
-	name = strings.TrimSpace(name)
-	return filepath.Join(api.dir, fmt.Sprintf("%s.md", name))
+func (api *API) delNode(name string) {
+	old, found := api.idx[name]
+	if !found {
+		return
+	}
+
+	for term := range old.Terms {
+		delete(api.terms[term], name)
+		if len(api.terms[term]) == 0 {
+			delete(api.terms, term)
+		}
+	}
+
+	for _, target := range old.Links {
+		delete(api.backlinks[target], name)
+		if len(api.backlinks[target]) == 0 {
+			delete(api.backlinks, target)
+		}
+	}
+	delete(api.idx, name)
+}
+
+// lock acquires the store lock if the store supports it.
+func (api *API) lock() (func(), error) {
+	if l, ok := api.store.(Locker); ok {
+		return l.Lock()
+	}
+	return func() {}, nil
+}
+
+func noteKey(name string) string {
+	return fmt.Sprintf("%s.md", strings.TrimSpace(name))
 }
 
-// Query represents filtering options for articles.
+// nameFromKey returns the name of the note stored at the key. Returns false
+// if the key is not a note (e.g., files under hidden directories).
+func nameFromKey(key string) (string, bool) {
+	if !strings.HasSuffix(key, ".md") || strings.HasPrefix(key, ".") || strings.Contains(key, "/.") {
+		return "", false
+	}
+	return strings.TrimSuffix(key, ".md"), true
+}
+
+// Sort fields supported by Query.
+const (
+	SortCreated   = "created"
+	SortUpdated   = "updated"
+	SortName      = "name"
+	SortRelevance = "relevance"
+)
+
+// Query represents filtering options for articles. Text is matched against
+// the note content, double-quoted parts of it are matched as phrases.
+// Notebook restricts the notes to the given notebook and its descendants.
+// CreatedRange and UpdatedRange are pairs of unix timestamps (inclusive).
+// Zero end of the range means now, UpdatedRange is ignored if not set.
+// IncludeTags and ExcludeTags match the tags and their descendants (e.g.,
+// 'team' matches 'team/payments') while TagFilters match the values of
+// key:value tags.
+//
+// Results are sorted by Sort field (created by default). Timestamps and
+// relevance are sorted in descending order and name in ascending order,
+// Reverse flips the order. Offset and Limit select a page of the results,
+// zero Limit means no limit.
 type Query struct {
-	NameLike     string   `json:"name_like"`
-	IncludeTags  []string `json:"include_tags"`
-	ExcludeTags  []string `json:"exclude_tags"`
-	CreatedRange [2]int64 `json:"created_range"`
+	NameLike     string      `json:"name_like"`
+	Notebook     string      `json:"notebook"`
+	Text         string      `json:"text"`
+	IncludeTags  []string    `json:"include_tags"`
+	ExcludeTags  []string    `json:"exclude_tags"`
+	TagFilters   []TagFilter `json:"tag_filters"`
+	CreatedRange [2]int64    `json:"created_range"`
+	UpdatedRange [2]int64    `json:"updated_range"`
+	Sort         string      `json:"sort"`
+	Reverse      bool        `json:"reverse"`
+	Offset       int         `json:"offset"`
+	Limit        int         `json:"limit"`
+
+	// Expr is an additional condition notes must satisfy. It is set by
+	// ParseQuery.
+	Expr Expr `json:"-"`
+}
+
+type indexFile struct {
+	Version int                  `json:"version"`
+	Notes   map[string]indexNode `json:"notes"`
 }
 
 type indexNode struct {
 	Tags      map[string]struct{} `json:"tags"`
+	Keys      map[string][]string `json:"keys,omitempty"`
+	Terms     map[string][]int    `json:"terms,omitempty"`
+	Length    int                 `json:"length,omitempty"`
+	Links     []string            `json:"links,omitempty"`
+	Tasks     []indexTask         `json:"tasks,omitempty"`
+	Due       int64               `json:"due,omitempty"`
+	Remind    int64               `json:"remind,omitempty"`
+	Size      int64               `json:"size"`
+	ModTime   int64               `json:"mod_time"`
 	CreatedAt int64               `json:"created_at"`
+	UpdatedAt int64               `json:"updated_at"`
+}
+
+func newIndexNode(n Note) indexNode {
+	return indexNode{
+		Tags:      arrToSet(n.Tags),
+		Keys:      tagKeys(n.Tags),
+		Terms:     indexTerms(n.Content),
+		Length:    len(tokenize(n.Content)),
+		Links:     linkNames(n.Name, n.Content),
+		Tasks:     parseTasks(n.Content),
+		Due:       dateUnix(n.Due),
+		Remind:    dateUnix(n.Remind),
+		CreatedAt: n.CreatedAt.Unix(),
+		UpdatedAt: n.UpdatedAt.Unix(),
+	}
 }
 
 func (q Query) isMatch(node indexNode) bool {
 	for _, tag := range q.IncludeTags {
-		if _, found := node.Tags[tag]; !found {
+		if !node.hasTag(tag) {
 			return false
 		}
 	}
 
 	for _, tag := range q.ExcludeTags {
-		if _, found := node.Tags[tag]; found {
+		if node.hasTag(tag) {
+			return false
+		}
+	}
+
+	for _, f := range q.TagFilters {
+		if !f.match(node) {
 			return false
 		}
 	}
 
-	after, before := q.CreatedRange[0], q.CreatedRange[1]
+	if q.UpdatedRange != [2]int64{} && !inRange(node.UpdatedAt, q.UpdatedRange) {
+		return false
+	}
+	return inRange(node.CreatedAt, q.CreatedRange)
+}
+
+func inRange(t int64, r [2]int64) bool {
+	after, before := r[0], r[1]
 	if before == 0 {
 		before = time.Now().Unix()
 	}
-	return node.CreatedAt >= after && node.CreatedAt <= before
+	return t >= after && t <= before
+}
+
+// sortNames sorts the names of notes by the given field. Ties are broken
+// by name so that the order is stable across searches.
+func (api *API) sortNames(names []string, field string, reverse bool, scores map[string]float64) error {
+	var less func(a, b string) bool
+	switch field {
+	case "", SortCreated:
+		less = func(a, b string) bool { return api.idx[a].CreatedAt > api.idx[b].CreatedAt }
+	case SortUpdated:
+		less = func(a, b string) bool { return api.idx[a].UpdatedAt > api.idx[b].UpdatedAt }
+	case SortRelevance:
+		less = func(a, b string) bool { return scores[a] > scores[b] }
+	case SortName:
+		less = func(a, b string) bool { return false }
+	default:
+		return fmt.Errorf("invalid sort field '%s'", field)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if reverse {
+			a, b = b, a
+		}
+
+		if less(a, b) {
+			return true
+		} else if less(b, a) {
+			return false
+		}
+		return a < b
+	})
+	return nil
+}
+
+func paginate(names []string, offset, limit int) []string {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(names) {
+		offset = len(names)
+	}
+	names = names[offset:]
+
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+	return names
 }
 
 func setToArray(set map[string]struct{}) []string {
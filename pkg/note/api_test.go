@@ -1 +1,588 @@
 package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_MemStore(t *testing.T) {
+	store := NewMemStore()
+
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if _, err := api.Put(Note{Name: "foo", Tags: []string{"test"}, Content: "# Foo"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := api.Put(Note{Name: "foo"}, true); !errors.Is(err, ErrConflict) {
+		t.Errorf("Put() expected ErrConflict, got %v", err)
+	}
+
+	// re-opening from the same store must see the saved note.
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	got, err := api.Get("foo")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	} else if got.Content != "# Foo" {
+		t.Errorf("Get() content = %q, want %q", got.Content, "# Foo")
+	}
+
+	res, err := api.Search(Query{IncludeTags: []string{"test"}}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	} else if len(res) != 1 || res[0].Name != "foo" {
+		t.Errorf("Search() got %v, want [foo]", res)
+	}
+
+	if err := api.Del("foo"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+	if _, err := api.Get("foo"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get("foo.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("store.Get() expected ErrNotFound, got %v", err)
+	}
+}
+
+func nopLog(_, _ string, _ ...interface{}) {}
+
+func TestAPI_Search_Text(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	for _, n := range []Note{
+		{Name: "kafka", Content: "Consumer rebalance happens when a member joins."},
+		{Name: "redis", Content: "Rebalance of slots, consumer groups in streams."},
+		{Name: "misc", Content: "Nothing interesting."},
+	} {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "rebalance", want: []string{"kafka", "redis"}},
+		{text: "Consumer REBALANCE", want: []string{"kafka", "redis"}},
+		{text: `"consumer rebalance"`, want: []string{"kafka"}},
+		{text: `"rebalance consumer"`, want: nil},
+		{text: "streams rebalance", want: []string{"redis"}},
+		{text: "zookeeper", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			res, err := api.Search(Query{Text: tt.text}, false)
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+
+			got := map[string]bool{}
+			for _, n := range res {
+				got[n.Name] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search() got %v, want %v", res, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("Search() missing '%s' in %v", name, res)
+				}
+			}
+		})
+	}
+}
+
+func TestAPI_Search_Ranked(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	for _, n := range []Note{
+		{Name: "day:1-Jan-2022", Content: "Looked into a kafka lag alert, nothing else."},
+		{Name: "kafka", Tags: []string{"tldr"}, Content: "Kafka is a distributed log. Kafka consumers form groups."},
+		{Name: "redis", Content: "In-memory store."},
+	} {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	res, err := api.Search(Query{Text: "kafka", Sort: SortRelevance}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	} else if len(res) != 2 {
+		t.Fatalf("Search() got %d hits, want 2", len(res))
+	}
+
+	if res[0].Name != "kafka" {
+		t.Errorf("Search() top hit = '%s', want 'kafka'", res[0].Name)
+	}
+	if res[0].Score <= res[1].Score || res[1].Score <= 0 {
+		t.Errorf("Search() unexpected scores %f, %f", res[0].Score, res[1].Score)
+	}
+	if want := "**Kafka** is a distributed log. **Kafka** consumers form groups."; res[0].Snippet != want {
+		t.Errorf("Search() snippet = %q, want %q", res[0].Snippet, want)
+	}
+	if res[0].Content != "" {
+		t.Errorf("Search() expected content to be omitted without loadNote")
+	}
+}
+
+func TestAPI_Trash(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if _, err := api.Put(Note{Name: "foo", Content: "# Foo"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := api.Del("foo"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+
+	trashed, err := api.Trash()
+	if err != nil {
+		t.Fatalf("Trash() unexpected error: %v", err)
+	} else if len(trashed) != 1 || trashed[0].Name != "foo" {
+		t.Fatalf("Trash() got %v, want [foo]", trashed)
+	}
+
+	if err := api.Index(true); err != nil {
+		t.Fatalf("Index() unexpected error: %v", err)
+	} else if _, _, count := api.Stats(); count != 0 {
+		t.Errorf("Index() must ignore trashed notes, got count=%d", count)
+	}
+
+	restored, err := api.Restore("foo")
+	if err != nil {
+		t.Fatalf("Restore() unexpected error: %v", err)
+	} else if restored.Content != "# Foo" {
+		t.Errorf("Restore() content = %q, want %q", restored.Content, "# Foo")
+	}
+	if _, err := api.Get("foo"); err != nil {
+		t.Errorf("Get() unexpected error after restore: %v", err)
+	}
+
+	if err := api.Del("foo"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+	if n, err := api.Purge(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("Purge() = (%d, %v), want (0, nil)", n, err)
+	}
+	if n, err := api.Purge(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("Purge() = (%d, %v), want (1, nil)", n, err)
+	}
+	if _, err := api.Restore("foo"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore() expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAPI_History(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	for _, content := range []string{"v1", "v2", "v2", "v3"} {
+		if _, err := api.Put(Note{Name: "foo", Content: content}, false); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	revs, err := api.History("foo")
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	} else if len(revs) != 2 {
+		t.Fatalf("History() got %d revisions, want 2", len(revs))
+	}
+
+	rev, err := api.Revision("foo", 1)
+	if err != nil {
+		t.Fatalf("Revision() unexpected error: %v", err)
+	} else if rev.Content != "v1" {
+		t.Errorf("Revision() content = %q, want %q", rev.Content, "v1")
+	}
+
+	diff, err := api.Diff("foo", 2, 0)
+	if err != nil {
+		t.Fatalf("Diff() unexpected error: %v", err)
+	} else if !strings.Contains(diff, "\n-v2\n+v3\n") {
+		t.Errorf("Diff() unexpected diff:\n%s", diff)
+	}
+
+	reverted, err := api.Revert("foo", 1)
+	if err != nil {
+		t.Fatalf("Revert() unexpected error: %v", err)
+	} else if reverted.Content != "v1" {
+		t.Errorf("Revert() content = %q, want %q", reverted.Content, "v1")
+	}
+
+	if revs, _ := api.History("foo"); len(revs) != 3 {
+		t.Errorf("History() got %d revisions after revert, want 3", len(revs))
+	}
+}
+
+func TestOpen_RecoverJournal(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if _, err := api.Put(Note{Name: "bar", Content: "# Bar"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	// simulate a crash after writing 'foo' and deleting 'bar' but before
+	// updating the index.
+	nt := Note{Name: "foo", Content: "# Foo"}
+	_ = nt.Validate()
+	_ = store.Put("foo.md", nt.ToMarkdown())
+	_ = store.Del("bar.md")
+	_ = store.Put(journalName, []byte(`{"op":"put","names":["foo","bar"]}`))
+
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if _, err := api.Get("foo"); err != nil {
+		t.Errorf("Get() unexpected error: %v", err)
+	}
+	if _, err := api.Get("bar"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get(journalName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("journal must be removed after recovery, got %v", err)
+	}
+}
+
+func TestOpen_RecoverJournal_Del(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if _, err := api.Put(Note{Name: name, Content: "# " + name}, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+	if err := api.Del("bar"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+
+	// simulate a crash after copying 'foo' to trash but before removing it.
+	startedAt := time.Now()
+	d, _ := store.Get("foo.md")
+	_ = store.Put(fmt.Sprintf("%s%d/foo.md", trashDir, startedAt.UnixNano()), d)
+	_ = store.Put(journalName, []byte(fmt.Sprintf(`{"op":"del","names":["foo"],"started_at":%d}`, startedAt.Unix())))
+
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if _, err := api.Get("foo"); err != nil {
+		t.Errorf("Get() unexpected error: %v", err)
+	}
+	trashed, err := api.Trash()
+	if err != nil {
+		t.Fatalf("Trash() unexpected error: %v", err)
+	} else if len(trashed) != 1 || trashed[0].Name != "bar" {
+		t.Errorf("Trash() got %+v, want only 'bar'", trashed)
+	}
+}
+
+func TestAPI_ConcurrentProfiles(t *testing.T) {
+	dir := t.TempDir()
+
+	open := func() *API {
+		ds, err := NewDirStore(dir, true)
+		if err != nil {
+			t.Fatalf("NewDirStore() unexpected error: %v", err)
+		}
+		api, err := Open("test", ds, nopLog)
+		if err != nil {
+			t.Fatalf("Open() unexpected error: %v", err)
+		}
+		return api
+	}
+
+	// both instances load the index before either of them writes.
+	api1, api2 := open(), open()
+	if _, err := api1.Put(Note{Name: "foo"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := api2.Put(Note{Name: "bar"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	if _, _, count := open().Stats(); count != 2 {
+		t.Errorf("expected both notes in index, got count=%d", count)
+	}
+}
+
+func TestOpen_Refresh(t *testing.T) {
+	store := &countingStore{MemStore: NewMemStore()}
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if _, err := api.Put(Note{Name: name, Content: "original"}, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	// edits made directly in the store.
+	_ = store.Put("foo.md", []byte("---\nname: foo\n---\n\nedited by hand"))
+	_ = store.Put("baz.md", []byte("no front-matter"))
+	_ = store.Del("bar.md")
+
+	store.gets = map[string]int{}
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if store.gets["foo.md"] != 1 || store.gets["baz.md"] != 1 {
+		t.Errorf("expected modified notes to be read once, got %v", store.gets)
+	}
+	if _, err := api.Get("bar"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() expected ErrNotFound for removed note, got %v", err)
+	}
+	if res, _ := api.Search(Query{Text: "hand"}, false); len(res) != 1 || res[0].Name != "foo" {
+		t.Errorf("Search() got %v, want [foo]", res)
+	}
+	if n, err := api.Get("baz"); err != nil {
+		t.Errorf("Get() unexpected error for added note: %v", err)
+	} else if n.Name != "baz" || n.CreatedAt.IsZero() || n.UpdatedAt.IsZero() {
+		t.Errorf("Get() must fill name and timestamps of hand-written note, got %+v", n)
+	}
+
+	// nothing changed, nothing must be read.
+	store.gets = map[string]int{}
+	if _, err := Open("test", store, nopLog); err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	for key := range store.gets {
+		if key != idxName && key != journalName {
+			t.Errorf("expected only the index to be read, got %v", store.gets)
+		}
+	}
+}
+
+type countingStore struct {
+	*MemStore
+	gets map[string]int
+}
+
+func (cs *countingStore) Get(key string) ([]byte, error) {
+	if cs.gets != nil {
+		cs.gets[key]++
+	}
+	return cs.MemStore.Get(key)
+}
+
+func TestAPI_Watch(t *testing.T) {
+	store := &watchingStore{MemStore: NewMemStore(), events: make(chan string)}
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- api.Watch(ctx) }()
+
+	_ = store.Put("foo.md", []byte("---\nname: foo\n---\n\nwatched"))
+	_ = store.Put("bad.md", []byte("---\n: bad\n---\n"))
+	store.events <- "foo.md"
+	store.events <- "bad.md"
+	store.events <- idxName
+	time.Sleep(2 * watchDebounce)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch() unexpected error: %v", err)
+	}
+
+	if _, err := api.Get("foo"); err != nil {
+		t.Errorf("Get() unexpected error: %v", err)
+	}
+	if _, err := api.Get("bad"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() expected ErrNotFound for unparsable note, got %v", err)
+	}
+}
+
+type watchingStore struct {
+	*MemStore
+	events chan string
+}
+
+func (ws *watchingStore) Watch(ctx context.Context, onChange func(key string)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key := <-ws.events:
+			onChange(key)
+		}
+	}
+}
+
+func TestAPI_Notebooks(t *testing.T) {
+	dir := t.TempDir()
+	ds, err := NewDirStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewDirStore() unexpected error: %v", err)
+	}
+
+	api, err := Open("test", ds, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	for _, name := range []string{"team/oncall", "team/payments/runbook", "kafka"} {
+		if _, err := api.Put(Note{Name: name}, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+	if _, err := api.Put(Note{Name: "team//x"}, true); err == nil {
+		t.Errorf("Put() expected error for empty notebook name")
+	}
+
+	if err := api.Index(true); err != nil {
+		t.Fatalf("Index() unexpected error: %v", err)
+	}
+
+	res, err := api.Search(Query{Notebook: "team"}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	} else if len(res) != 2 {
+		t.Errorf("Search() got %v, want notes in 'team'", res)
+	}
+
+	tree, err := api.Tree("")
+	if err != nil {
+		t.Fatalf("Tree() unexpected error: %v", err)
+	}
+	if tree.Count != 3 || len(tree.Notes) != 1 || len(tree.Notebooks) != 1 {
+		t.Fatalf("Tree() unexpected root: %+v", tree)
+	}
+	team := tree.Notebooks[0]
+	if team.Path != "team" || team.Count != 2 || len(team.Notebooks) != 1 || team.Notebooks[0].Path != "team/payments" {
+		t.Errorf("Tree() unexpected notebook: %+v", team)
+	}
+
+	if _, err := api.Tree("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Tree() expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAPI_Put_Timestamps(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if _, err := api.Put(Note{Name: "old", CreatedAt: created}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := api.Put(Note{Name: "new"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	updated, err := api.Put(Note{Name: "old", Content: "edited"}, false)
+	if err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	} else if !updated.CreatedAt.Equal(created) {
+		t.Errorf("Put() CreatedAt = %v, want %v", updated.CreatedAt, created)
+	} else if !updated.UpdatedAt.After(created) {
+		t.Errorf("Put() UpdatedAt = %v, must be after %v", updated.UpdatedAt, created)
+	}
+
+	res, err := api.Search(Query{CreatedRange: [2]int64{0, time.Now().Add(-time.Hour).Unix()}}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	} else if len(res) != 1 || res[0].Name != "old" {
+		t.Errorf("Search() by created got %v, want [old]", res)
+	}
+
+	res, err = api.Search(Query{UpdatedRange: [2]int64{time.Now().Add(-time.Hour).Unix(), 0}}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	} else if len(res) != 2 {
+		t.Errorf("Search() by updated got %v, want both notes", res)
+	}
+}
+
+func TestAPI_Search_SortAndPaginate(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, name := range []string{"nc", "na", "nd", "nb"} {
+		n := Note{Name: name, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+	// same creation time as 'nc', tie must be broken by name.
+	if _, err := api.Put(Note{Name: "ne", CreatedAt: base}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{name: "DefaultCreated", q: Query{}, want: "nb,nd,na,nc,ne"},
+		{name: "CreatedReverse", q: Query{Sort: SortCreated, Reverse: true}, want: "ne,nc,na,nd,nb"},
+		{name: "Name", q: Query{Sort: SortName}, want: "na,nb,nc,nd,ne"},
+		{name: "NameReverse", q: Query{Sort: SortName, Reverse: true}, want: "ne,nd,nc,nb,na"},
+		{name: "Limit", q: Query{Sort: SortName, Limit: 2}, want: "na,nb"},
+		{name: "Page", q: Query{Sort: SortName, Limit: 2, Offset: 4}, want: "ne"},
+		{name: "OffsetBeyond", q: Query{Sort: SortName, Offset: 10}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := api.Search(tt.q, false)
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, h := range res {
+				names = append(names, h.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("Search() got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := api.Search(Query{Sort: "size"}, false); err == nil {
+		t.Errorf("Search() expected error for invalid sort field")
+	}
+}
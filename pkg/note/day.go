@@ -0,0 +1,31 @@
+package note
+
+import "time"
+
+// DayNoteName returns the name of the day note for the given time (e.g.,
+// 'day:16-Oct-2022').
+func (api *API) DayNoteName(t time.Time) string {
+	return api.PeriodNoteName(PeriodDay, t)
+}
+
+// ParseDayNoteName returns the date of the day note with given name. Returns
+// false if the name is not of a day note.
+func (api *API) ParseDayNoteName(name string) (time.Time, bool) {
+	return parsePeriod(PeriodDay, api.periodFormats[PeriodDay], name)
+}
+
+// PrevDayNote returns the name of the latest day note before the day of the
+// given time. Returns false if there is none.
+func (api *API) PrevDayNote(t time.Time) (string, bool) {
+	day := PeriodStart(PeriodDay, t)
+
+	var prev string
+	var prevDate time.Time
+	for name := range api.idx {
+		d, ok := api.ParseDayNoteName(name)
+		if ok && d.Before(day) && d.After(prevDate) {
+			prev, prevDate = name, d
+		}
+	}
+	return prev, prev != ""
+}
@@ -0,0 +1,135 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // one of ' ', '-', '+'
+	text string
+}
+
+// UnifiedDiff returns the line-based difference between a and b in unified
+// diff format. Returns empty string if both are same.
+func UnifiedDiff(fromName, toName, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var hunks strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// extend the hunk while changes are within 2*context lines of each other.
+		start, end := i, i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext + 1
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		aStart, bStart := 1, 1
+		for _, l := range lines[:from] {
+			if l.op != '+' {
+				aStart++
+			}
+			if l.op != '-' {
+				bStart++
+			}
+		}
+
+		var aLen, bLen int
+		var body strings.Builder
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				aLen++
+			}
+			if l.op != '-' {
+				bLen++
+			}
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+		}
+
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&hunks, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		hunks.WriteString(body.String())
+		i = to
+	}
+
+	if hunks.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", fromName, toName, hunks.String())
+}
+
+// diffLines computes the edit script from a to b using the longest common
+// subsequence of lines.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var res []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			res = append(res, diffLine{op: ' ', text: a[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			res = append(res, diffLine{op: '-', text: a[i]})
+			i++
+		} else {
+			res = append(res, diffLine{op: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		res = append(res, diffLine{op: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		res = append(res, diffLine{op: '+', text: b[j]})
+	}
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
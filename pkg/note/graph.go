@@ -0,0 +1,267 @@
+package note
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kinds of graph edges.
+const (
+	EdgeLink = "link"
+	EdgeTag  = "tag"
+)
+
+// GraphOptions controls the notes and edges included in a graph.
+type GraphOptions struct {
+	// Query selects the notes in the graph. Sort and pagination are ignored.
+	Query Query
+
+	// Root, if set, limits the graph to notes reachable from the root note
+	// within Depth edges (in either direction). Zero Depth means no limit.
+	Root  string
+	Depth int
+
+	// SharedTags adds edges between notes that have a tag in common.
+	SharedTags bool
+}
+
+// Graph is a graph of notes connected by links and shared tags.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes" yaml:"nodes"`
+	Edges []GraphEdge `json:"edges" yaml:"edges"`
+}
+
+// GraphNode is a note in the graph.
+type GraphNode struct {
+	ID   string   `json:"id" yaml:"id"`
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// GraphEdge connects two notes. Link edges are directed from the note having
+// the link. Tag edges are undirected, From is the smaller name and Label has
+// the shared tags.
+type GraphEdge struct {
+	From  string `json:"from" yaml:"from"`
+	To    string `json:"to" yaml:"to"`
+	Kind  string `json:"kind" yaml:"kind"`
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+}
+
+// Graph returns the graph of notes and links between them as per the
+// options. Links to notes that do not exist are not included.
+func (api *API) Graph(opts GraphOptions) (*Graph, error) {
+	q := opts.Query
+	q.Offset, q.Limit = 0, 0
+	if q.Sort == SortRelevance {
+		q.Sort = ""
+	}
+
+	hits, err := api.Search(q, false)
+	if err != nil {
+		return nil, err
+	}
+
+	included := map[string]struct{}{}
+	for _, h := range hits {
+		included[h.Name] = struct{}{}
+	}
+
+	edges := api.graphEdges(included, opts.SharedTags)
+
+	if root := strings.TrimSpace(opts.Root); root != "" {
+		if _, found := included[root]; !found {
+			return nil, fmt.Errorf("%w: note with name '%s' in graph", ErrNotFound, root)
+		}
+		included = reachable(root, opts.Depth, edges)
+	}
+
+	g := &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for name := range included {
+		tags := setToArray(api.idx[name].Tags)
+		sort.Strings(tags)
+		g.Nodes = append(g.Nodes, GraphNode{ID: name, Tags: tags})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+
+	for _, e := range edges {
+		_, hasFrom := included[e.From]
+		_, hasTo := included[e.To]
+		if hasFrom && hasTo {
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	return g, nil
+}
+
+// graphEdges returns the edges between the given notes, sorted.
+func (api *API) graphEdges(names map[string]struct{}, sharedTags bool) []GraphEdge {
+	var edges []GraphEdge
+	for name := range names {
+		for _, target := range api.idx[name].Links {
+			if _, found := names[target]; found && target != name {
+				edges = append(edges, GraphEdge{From: name, To: target, Kind: EdgeLink})
+			}
+		}
+	}
+
+	if sharedTags {
+		byTag := map[string][]string{}
+		for name := range names {
+			for tag := range api.idx[name].Tags {
+				byTag[tag] = append(byTag[tag], name)
+			}
+		}
+
+		shared := map[[2]string][]string{}
+		for tag, tagged := range byTag {
+			sort.Strings(tagged)
+			for i := range tagged {
+				for j := i + 1; j < len(tagged); j++ {
+					pair := [2]string{tagged[i], tagged[j]}
+					shared[pair] = append(shared[pair], tag)
+				}
+			}
+		}
+
+		for pair, tags := range shared {
+			sort.Strings(tags)
+			edges = append(edges, GraphEdge{From: pair[0], To: pair[1], Kind: EdgeTag, Label: strings.Join(tags, ", ")})
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		} else if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return edges
+}
+
+// reachable returns the notes reachable from root within depth edges. Edges
+// are followed in both directions.
+func reachable(root string, depth int, edges []GraphEdge) map[string]struct{} {
+	adj := map[string][]string{}
+	for _, e := range edges {
+		adj[e.From] = append(adj[e.From], e.To)
+		adj[e.To] = append(adj[e.To], e.From)
+	}
+
+	seen := map[string]struct{}{root: {}}
+	frontier := []string{root}
+	for level := 0; len(frontier) > 0 && (depth <= 0 || level < depth); level++ {
+		var next []string
+		for _, name := range frontier {
+			for _, other := range adj[name] {
+				if _, found := seen[other]; !found {
+					seen[other] = struct{}{}
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	return seen
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph notes {\n")
+	for _, n := range g.Nodes {
+		sb.WriteString(fmt.Sprintf("  %s;\n", dotQuote(n.ID)))
+	}
+	for _, e := range g.Edges {
+		if e.Kind == EdgeTag {
+			sb.WriteString(fmt.Sprintf("  %s -> %s [dir=none, style=dashed, label=%s];\n",
+				dotQuote(e.From), dotQuote(e.To), dotQuote(e.Label)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To)))
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// WriteGraphML writes the graph in GraphML format.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source   string `xml:"source,attr"`
+		Target   string `xml:"target,attr"`
+		Directed bool   `xml:"directed,attr"`
+		Data     []data `xml:"data"`
+	}
+	type key struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type graph struct {
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{ID: "tags", For: "node", Name: "tags", Type: "string"},
+			{ID: "kind", For: "edge", Name: "kind", Type: "string"},
+			{ID: "label", For: "edge", Name: "label", Type: "string"},
+		},
+		Graph: graph{EdgeDefault: "directed"},
+	}
+	for _, n := range g.Nodes {
+		gn := node{ID: n.ID}
+		if len(n.Tags) > 0 {
+			gn.Data = []data{{Key: "tags", Value: strings.Join(n.Tags, ",")}}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+	for _, e := range g.Edges {
+		ge := edge{Source: e.From, Target: e.To, Directed: e.Kind != EdgeTag}
+		ge.Data = append(ge.Data, data{Key: "kind", Value: e.Kind})
+		if e.Label != "" {
+			ge.Data = append(ge.Data, data{Key: "label", Value: e.Label})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, ge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
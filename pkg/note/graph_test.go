@@ -0,0 +1,135 @@
+package note
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAPI_Graph(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "na", Tags: []string{"infra"}, Content: "[[nb]] and [[missing]]"},
+		{Name: "nb", Tags: []string{"infra", "oncall"}, Content: "[C](nc.md)"},
+		{Name: "nc", Tags: []string{"oncall"}, Content: "[[nd]]"},
+		{Name: "nd", Tags: []string{"draft"}},
+		{Name: "ne", Tags: []string{"infra"}},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	render := func(g *Graph) string {
+		var nodes, edges []string
+		for _, n := range g.Nodes {
+			nodes = append(nodes, n.ID)
+		}
+		for _, e := range g.Edges {
+			edges = append(edges, fmt.Sprintf("%s-%s>%s", e.From, e.Kind, e.To))
+		}
+		return strings.Join(nodes, ",") + " | " + strings.Join(edges, ",")
+	}
+
+	tests := []struct {
+		name string
+		opts GraphOptions
+		want string
+	}{
+		{
+			name: "All",
+			opts: GraphOptions{},
+			want: "na,nb,nc,nd,ne | na-link>nb,nb-link>nc,nc-link>nd",
+		},
+		{
+			name: "SharedTags",
+			opts: GraphOptions{SharedTags: true},
+			want: "na,nb,nc,nd,ne | na-link>nb,na-tag>nb,na-tag>ne,nb-link>nc,nb-tag>nc,nb-tag>ne,nc-link>nd",
+		},
+		{
+			name: "Query",
+			opts: GraphOptions{Query: Query{ExcludeTags: []string{"draft"}}},
+			want: "na,nb,nc,ne | na-link>nb,nb-link>nc",
+		},
+		{
+			name: "RootDepth",
+			opts: GraphOptions{Root: "nb", Depth: 1},
+			want: "na,nb,nc | na-link>nb,nb-link>nc",
+		},
+		{
+			name: "RootUnlimited",
+			opts: GraphOptions{Root: "nd"},
+			want: "na,nb,nc,nd | na-link>nb,nb-link>nc,nc-link>nd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := api.Graph(tt.opts)
+			if err != nil {
+				t.Fatalf("Graph() unexpected error: %v", err)
+			}
+			if got := render(g); got != tt.want {
+				t.Errorf("Graph() got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// tags of nodes must be in a stable order.
+	for i := 0; i < 10; i++ {
+		g, err := api.Graph(GraphOptions{Root: "nb", Depth: 0})
+		if err != nil {
+			t.Fatalf("Graph() unexpected error: %v", err)
+		}
+		for _, n := range g.Nodes {
+			if n.ID == "nb" && !reflect.DeepEqual(n.Tags, []string{"infra", "oncall"}) {
+				t.Fatalf("Graph() node tags got %v, want [infra oncall]", n.Tags)
+			}
+		}
+	}
+
+	if _, err := api.Graph(GraphOptions{Root: "nd", Query: Query{ExcludeTags: []string{"draft"}}}); err == nil {
+		t.Errorf("Graph() expected error for root outside the query")
+	}
+}
+
+func TestGraph_Write(t *testing.T) {
+	g := &Graph{
+		Nodes: []GraphNode{{ID: "na", Tags: []string{"x"}}, {ID: `n"b`}},
+		Edges: []GraphEdge{
+			{From: "na", To: `n"b`, Kind: EdgeLink},
+			{From: "na", To: `n"b`, Kind: EdgeTag, Label: "x"},
+		},
+	}
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() unexpected error: %v", err)
+	}
+	wantDOT := "digraph notes {\n  \"na\";\n  \"n\\\"b\";\n  \"na\" -> \"n\\\"b\";\n" +
+		"  \"na\" -> \"n\\\"b\" [dir=none, style=dashed, label=\"x\"];\n}\n"
+	if dot.String() != wantDOT {
+		t.Errorf("WriteDOT() got:\n%s\nwant:\n%s", dot.String(), wantDOT)
+	}
+
+	var gml bytes.Buffer
+	if err := g.WriteGraphML(&gml); err != nil {
+		t.Fatalf("WriteGraphML() unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`<node id="na">`,
+		`<data key="tags">x</data>`,
+		`<edge source="na" target="n&#34;b" directed="true">`,
+		`<edge source="na" target="n&#34;b" directed="false">`,
+	} {
+		if !strings.Contains(gml.String(), want) {
+			t.Errorf("WriteGraphML() output does not contain %s:\n%s", want, gml.String())
+		}
+	}
+}
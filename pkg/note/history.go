@@ -0,0 +1,183 @@
+package note
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyDir = ".history/"
+
+// Revision represents a prior version of a note. Revision IDs start at 1 for
+// the oldest revision of a note.
+type Revision struct {
+	ID      int       `json:"id" yaml:"id"`
+	Name    string    `json:"name" yaml:"name"`
+	SavedAt time.Time `json:"saved_at" yaml:"saved_at"`
+	Size    int64     `json:"size" yaml:"size"`
+
+	key string
+}
+
+// History returns all the prior revisions of the note, oldest first.
+func (api *API) History(name string) ([]Revision, error) {
+	name = strings.TrimSpace(name)
+	prefix := historyPrefix(name)
+
+	entries, err := api.store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Revision{}
+	for _, entry := range entries {
+		rest := strings.TrimPrefix(entry.Key, prefix)
+		if strings.Contains(rest, "/") || !strings.HasSuffix(rest, ".md") {
+			continue // revision of a nested note.
+		}
+
+		nanos, err := strconv.ParseInt(strings.TrimSuffix(rest, ".md"), 10, 64)
+		if err != nil {
+			api.log("warn", "ignoring unknown history entry '%s'", entry.Key)
+			continue
+		}
+
+		res = append(res, Revision{
+			Name:    name,
+			SavedAt: time.Unix(0, nanos),
+			Size:    entry.Size,
+			key:     entry.Key,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].SavedAt.Before(res[j].SavedAt)
+	})
+	for i := range res {
+		res[i].ID = i + 1
+	}
+	return res, nil
+}
+
+// Revision returns the note as it was at the given revision. Revision 0
+// refers to the current version of the note.
+func (api *API) Revision(name string, rev int) (*Note, error) {
+	d, err := api.revisionData(name, rev)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(d)
+}
+
+// Diff returns the unified diff of the note between two revisions. Revision
+// 0 refers to the current version of the note.
+func (api *API) Diff(name string, from, to int) (string, error) {
+	a, err := api.revisionData(name, from)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := api.revisionData(name, to)
+	if err != nil {
+		return "", err
+	}
+
+	return UnifiedDiff(revisionLabel(name, from), revisionLabel(name, to), string(a), string(b)), nil
+}
+
+// Revert restores the note to the given revision. Current version of the
+// note is saved as a new revision.
+func (api *API) Revert(name string, rev int) (*Note, error) {
+	if rev == 0 {
+		return nil, fmt.Errorf("cannot revert to current revision")
+	}
+
+	n, err := api.Revision(name, rev)
+	if err != nil {
+		return nil, err
+	}
+	n.Name = strings.TrimSpace(name)
+
+	return api.Put(*n, false)
+}
+
+// saveRevision saves the current version of the note as a revision if its
+// content differs from both the latest revision and the updated note.
+func (api *API) saveRevision(updated Note) error {
+	cur, err := api.store.Get(noteKey(updated.Name))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	curNote, err := Parse(cur)
+	if err != nil {
+		return err
+	}
+
+	digest := contentDigest(*curNote)
+	if digest == contentDigest(updated) {
+		return nil
+	}
+
+	revs, err := api.History(updated.Name)
+	if err != nil {
+		return err
+	} else if len(revs) > 0 {
+		last, err := api.Revision(updated.Name, len(revs))
+		if err == nil && contentDigest(*last) == digest {
+			return nil
+		}
+	}
+
+	key := fmt.Sprintf("%s%d.md", historyPrefix(updated.Name), time.Now().UnixNano())
+	return api.store.Put(key, cur)
+}
+
+func (api *API) revisionData(name string, rev int) ([]byte, error) {
+	name = strings.TrimSpace(name)
+	if rev == 0 {
+		if _, found := api.idx[name]; !found {
+			return nil, fmt.Errorf("%w: note with name '%s'", ErrNotFound, name)
+		}
+		return api.store.Get(noteKey(name))
+	}
+
+	revs, err := api.History(name)
+	if err != nil {
+		return nil, err
+	} else if rev < 0 || rev > len(revs) {
+		return nil, fmt.Errorf("%w: revision %d of note '%s'", ErrNotFound, rev, name)
+	}
+	return api.store.Get(revs[rev-1].key)
+}
+
+// contentDigest returns a hash of the note content and tags. Timestamps are
+// excluded so that saving a note without changes results in same digest.
+func contentDigest(n Note) string {
+	tags := append([]string(nil), n.Tags...)
+	sort.Strings(tags)
+
+	h := sha256.New()
+	h.Write([]byte(strings.Join(tags, "\n")))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(n.Content)))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func historyPrefix(name string) string {
+	return fmt.Sprintf("%s%s/", historyDir, name)
+}
+
+func revisionLabel(name string, rev int) string {
+	if rev == 0 {
+		return fmt.Sprintf("%s (current)", name)
+	}
+	return fmt.Sprintf("%s (rev %d)", name, rev)
+}
@@ -0,0 +1,110 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const journalName = "notes_journal.json"
+
+// journal records an operation that modifies notes and the index. It exists
+// in the store only while the operation is in progress. If it is found when
+// opening, the operation was interrupted and the index entries of the notes
+// are reconciled with the store.
+type journal struct {
+	Op        string   `json:"op"`
+	Names     []string `json:"names"`
+	StartedAt int64    `json:"started_at"`
+}
+
+// apply runs fn as a journaled operation on the named notes. The store is
+// locked and the index is re-loaded before running fn so that changes made by
+// other processes are not lost.
+func (api *API) apply(op string, names []string, fn func() error) error {
+	unlock, err := api.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := api.loadIdx(); err != nil {
+		return err
+	} else if err := api.recoverJournal(); err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(journal{Op: op, Names: names, StartedAt: time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+
+	if err := api.store.Put(journalName, d); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if recErr := api.reconcile(names); recErr != nil {
+			api.log("warn", "failed to reconcile index after failed %s: %v", op, recErr)
+			return err
+		}
+		_ = api.store.Del(journalName)
+		return err
+	}
+
+	return api.store.Del(journalName)
+}
+
+// recoverJournal completes the recovery of an operation that was interrupted.
+func (api *API) recoverJournal() error {
+	d, err := api.store.Get(journalName)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var j journal
+	if err := json.Unmarshal(d, &j); err != nil {
+		api.log("warn", "journal is corrupt, re-building index")
+		if err := api.refresh(true, false); err != nil {
+			return err
+		}
+	} else {
+		api.log("warn", "recovering from interrupted '%s' of %v", j.Op, j.Names)
+		if err := api.reconcile(j.Names); err != nil {
+			return err
+		}
+
+		// a delete copies the note to trash before removing it, undo the
+		// copy if the note was not removed.
+		if j.Op == "del" {
+			if err := api.undoTrash(j.Names, time.Unix(j.StartedAt, 0)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return api.store.Del(journalName)
+}
+
+// reconcile updates index entries of the named notes to match the store.
+func (api *API) reconcile(names []string) error {
+	for _, name := range names {
+		entry, err := api.store.Stat(noteKey(name))
+		if errors.Is(err, ErrNotFound) {
+			api.delNode(name)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		node, err := api.readNode(*entry)
+		if err != nil {
+			return err
+		}
+		api.setNode(name, node)
+	}
+	return api.syncIdx()
+}
@@ -0,0 +1,232 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of links.
+const (
+	LinkWiki       = "wiki"
+	LinkMarkdown   = "markdown"
+	LinkAttachment = "attachment"
+)
+
+var (
+	wikiLinkExp   = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|([^\[\]]*))?\]\]`)
+	mdLinkExp     = regexp.MustCompile(`!?\[([^\]]*)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+	inlineCodeExp = regexp.MustCompile("`[^`]*`")
+)
+
+// Link is a reference from a note to another note using the wiki-link syntax
+// '[[name]]' or '[[name|label]]', or a markdown link to another note (e.g.,
+// '[label](../kafka.md)'). Markdown links to other files are attachments and
+// their Name is the path of the file in the profile. Line is the 1-based line
+// number of the link in the note content.
+type Link struct {
+	Name   string `json:"name" yaml:"name"`
+	Label  string `json:"label,omitempty" yaml:"label,omitempty"`
+	Kind   string `json:"kind" yaml:"kind"`
+	Line   int    `json:"line" yaml:"line"`
+	Exists bool   `json:"exists" yaml:"exists"`
+}
+
+// Links returns the links in the note with given name in the order they
+// appear. Exists is set if the linked note or attachment exists.
+func (api *API) Links(name string) ([]Link, error) {
+	n, err := api.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	links := parseLinks(strings.TrimSpace(name), n.Content)
+	for i, l := range links {
+		if l.Kind != LinkAttachment {
+			_, links[i].Exists = api.idx[l.Name]
+			continue
+		}
+
+		_, err := api.store.Stat(l.Name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		links[i].Exists = err == nil
+	}
+	return links, nil
+}
+
+// Backlinks returns the names of notes that link to the note with given name,
+// sorted by name.
+func (api *API) Backlinks(name string) ([]string, error) {
+	name = strings.TrimSpace(name)
+	if _, found := api.idx[name]; !found {
+		return nil, fmt.Errorf("%w: note with name '%s'", ErrNotFound, name)
+	}
+
+	res := []string{}
+	for src := range api.backlinks[name] {
+		res = append(res, src)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// parseLinks returns the links in the content of the named note. Links in code
+// blocks and inline code, and links to external URLs are ignored. Markdown
+// links are relative to the notebook of the note unless they start with '/'.
+// '#section' suffix of the linked name is dropped.
+func parseLinks(from, content string) []Link {
+	var links []Link
+	forEachLine(content, func(i int, raw string) string {
+		line := inlineCodeExp.ReplaceAllStringFunc(raw, func(s string) string {
+			return strings.Repeat(" ", len(s))
+		})
+
+		// wiki and markdown links are collected separately and ordered by
+		// their position in the line.
+		var lineLinks []Link
+		var cols []int
+		for _, m := range wikiLinkExp.FindAllStringSubmatchIndex(line, -1) {
+			name := line[m[2]:m[3]]
+			if idx := strings.IndexByte(name, '#'); idx >= 0 {
+				name = name[:idx]
+			}
+
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			var label string
+			if m[4] >= 0 {
+				label = strings.TrimSpace(line[m[4]:m[5]])
+			}
+			lineLinks = append(lineLinks, Link{Name: name, Label: label, Kind: LinkWiki, Line: i + 1})
+			cols = append(cols, m[0])
+		}
+
+		for _, m := range mdLinkExp.FindAllStringSubmatchIndex(line, -1) {
+			if l, ok := resolveLink(from, line[m[4]:m[5]]); ok {
+				l.Label, l.Line = strings.TrimSpace(line[m[2]:m[3]]), i+1
+				lineLinks = append(lineLinks, l)
+				cols = append(cols, m[0])
+			}
+		}
+
+		sort.Sort(byColumn{links: lineLinks, cols: cols})
+		links = append(links, lineLinks...)
+		return raw
+	})
+	return links
+}
+
+type byColumn struct {
+	links []Link
+	cols  []int
+}
+
+func (bc byColumn) Len() int           { return len(bc.links) }
+func (bc byColumn) Less(i, j int) bool { return bc.cols[i] < bc.cols[j] }
+func (bc byColumn) Swap(i, j int) {
+	bc.links[i], bc.links[j] = bc.links[j], bc.links[i]
+	bc.cols[i], bc.cols[j] = bc.cols[j], bc.cols[i]
+}
+
+// resolveLink resolves target of a markdown link in the named note. Returns
+// false if the target is an external URL or is outside the profile.
+func resolveLink(from, target string) (Link, bool) {
+	if idx := strings.IndexByte(target, '#'); idx >= 0 {
+		target = target[:idx]
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return Link{}, false
+	}
+
+	p := u.Path
+	if strings.HasPrefix(p, "/") {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+	} else {
+		p = path.Join(path.Dir(from), p)
+	}
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return Link{}, false
+	}
+
+	if strings.HasSuffix(p, ".md") {
+		return Link{Name: strings.TrimSuffix(p, ".md"), Kind: LinkMarkdown}, true
+	}
+	return Link{Name: p, Kind: LinkAttachment}, true
+}
+
+// linkNames returns the distinct names of notes linked in the content of the
+// named note. Attachments are not included.
+func linkNames(from, content string) []string {
+	seen := map[string]struct{}{}
+
+	var names []string
+	for _, l := range parseLinks(from, content) {
+		if l.Kind == LinkAttachment {
+			continue
+		} else if _, found := seen[l.Name]; !found {
+			seen[l.Name] = struct{}{}
+			names = append(names, l.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LinkReport lists broken links and orphan notes in a profile.
+type LinkReport struct {
+	Broken  []BrokenLink `json:"broken" yaml:"broken"`
+	Orphans []string     `json:"orphans" yaml:"orphans"`
+}
+
+// BrokenLink is a link in a note to a note or attachment that does not exist.
+type BrokenLink struct {
+	Note string `json:"note" yaml:"note"`
+	Link `yaml:",inline"`
+}
+
+// CheckLinks reads all notes in the profile and reports links to notes or
+// attachments that do not exist, and notes that no other note links to.
+func (api *API) CheckLinks() (*LinkReport, error) {
+	var names []string
+	for name := range api.idx {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &LinkReport{Broken: []BrokenLink{}, Orphans: []string{}}
+	for _, name := range names {
+		links, err := api.Links(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range links {
+			if !l.Exists {
+				report.Broken = append(report.Broken, BrokenLink{Note: name, Link: l})
+			}
+		}
+
+		orphan := true
+		for src := range api.backlinks[name] {
+			if src != name {
+				orphan = false
+				break
+			}
+		}
+		if orphan {
+			report.Orphans = append(report.Orphans, name)
+		}
+	}
+	return report, nil
+}
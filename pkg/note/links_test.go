@@ -0,0 +1,149 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	content := strings.Join([]string{
+		"See [[kafka]] and [[team/oncall|the oncall notes]].",
+		"Also [[kafka#setup]] and [[ redis ]] but not [[]] or [[|x]].",
+		"```",
+		"[[in-code-block]]",
+		"```",
+		"Inline `[[in-code]]` is ignored.",
+		"[Runbook](runbook.md#alerts) before [[kafka]], ![graph](img/lag.png) and [root](/kafka.md).",
+		"External [site](https://example.com/a.md), [mail](mailto:a@b.c), [top](#top), [up](../../x.md).",
+	}, "\n")
+
+	want := []Link{
+		{Name: "kafka", Kind: LinkWiki, Line: 1},
+		{Name: "team/oncall", Label: "the oncall notes", Kind: LinkWiki, Line: 1},
+		{Name: "kafka", Kind: LinkWiki, Line: 2},
+		{Name: "redis", Kind: LinkWiki, Line: 2},
+		{Name: "team/runbook", Label: "Runbook", Kind: LinkMarkdown, Line: 7},
+		{Name: "kafka", Kind: LinkWiki, Line: 7},
+		{Name: "team/img/lag.png", Label: "graph", Kind: LinkAttachment, Line: 7},
+		{Name: "kafka", Label: "root", Kind: LinkMarkdown, Line: 7},
+	}
+	if got := parseLinks("team/notes", content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLinks() got = %v, want %v", got, want)
+	}
+}
+
+func TestAPI_Links(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "kafka", Content: "Kafka notes. See [[redis]]."},
+		{Name: "redis", Content: "Redis notes."},
+		{Name: "oncall", Content: "Runbooks: [[kafka|Kafka]], [Redis](redis.md) and [[missing]]."},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	links, err := api.Links("oncall")
+	if err != nil {
+		t.Fatalf("Links() unexpected error: %v", err)
+	}
+	want := []Link{
+		{Name: "kafka", Label: "Kafka", Kind: LinkWiki, Line: 1, Exists: true},
+		{Name: "redis", Label: "Redis", Kind: LinkMarkdown, Line: 1, Exists: true},
+		{Name: "missing", Kind: LinkWiki, Line: 1},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("Links() got = %v, want %v", links, want)
+	}
+
+	backlinks := func(name string) []string {
+		res, err := api.Backlinks(name)
+		if err != nil {
+			t.Fatalf("Backlinks() unexpected error: %v", err)
+		}
+		return res
+	}
+
+	if got := backlinks("redis"); !reflect.DeepEqual(got, []string{"kafka", "oncall"}) {
+		t.Errorf("Backlinks() got = %v, want [kafka oncall]", got)
+	}
+
+	// updating and deleting notes must update backlinks.
+	if _, err := api.Put(Note{Name: "kafka", Content: "No links."}, false); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if got := backlinks("redis"); !reflect.DeepEqual(got, []string{"oncall"}) {
+		t.Errorf("Backlinks() after update got = %v, want [oncall]", got)
+	}
+	if err := api.Del("oncall"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+	if got := backlinks("redis"); len(got) != 0 {
+		t.Errorf("Backlinks() after delete got = %v, want none", got)
+	}
+
+	// backlinks must survive re-opening from the persisted index.
+	if _, err := api.Put(Note{Name: "oncall", Content: "[[redis]]"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if got := backlinks("redis"); !reflect.DeepEqual(got, []string{"oncall"}) {
+		t.Errorf("Backlinks() after re-open got = %v, want [oncall]", got)
+	}
+
+	if _, err := api.Backlinks("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Backlinks() expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAPI_CheckLinks(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if err := store.Put("team/img/ok.png", []byte("png")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "index", Content: "[[team/a]]\n[B](team/b.md)\n[[gone]]"},
+		{Name: "team/a", Content: "![ok](img/ok.png) ![bad](img/bad.png)\n[Index](../index.md)"},
+		{Name: "team/b"},
+		{Name: "lonely", Content: "[[lonely]] links to itself."},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	report, err := api.CheckLinks()
+	if err != nil {
+		t.Fatalf("CheckLinks() unexpected error: %v", err)
+	}
+
+	want := &LinkReport{
+		Broken: []BrokenLink{
+			{Note: "index", Link: Link{Name: "gone", Kind: LinkWiki, Line: 3}},
+			{Note: "team/a", Link: Link{Name: "team/img/bad.png", Label: "bad", Kind: LinkAttachment, Line: 1}},
+		},
+		Orphans: []string{"lonely"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CheckLinks() got = %+v, want %+v", report, want)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package note
+
+import "os"
+
+// file locking is not supported on this platform, lock is always acquired.
+func tryLockFile(_ *os.File) (bool, error) { return true, nil }
+
+func unlockFile(_ *os.File) error { return nil }
@@ -50,13 +50,16 @@ func Parse(md []byte) (*Note, error) {
 	return &ar, nil
 }
 
-// Note represents a snippet of information with additional metadata.
+// Note represents a snippet of information with additional metadata. Due
+// and Remind are optional dates (e.g., 'due: 2006-01-02') shown in agenda.
 type Note struct {
-	Name      string    `json:"name" yaml:"name"`
-	Tags      []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
-	Content   string    `json:"content,omitempty" yaml:"content,omitempty"`
-	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+	Name      string     `json:"name" yaml:"name"`
+	Tags      []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Due       *time.Time `json:"due,omitempty" yaml:"due,omitempty"`
+	Remind    *time.Time `json:"remind,omitempty" yaml:"remind,omitempty"`
+	Content   string     `json:"content,omitempty" yaml:"content,omitempty"`
+	CreatedAt time.Time  `json:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" yaml:"updated_at"`
 }
 
 func (nt *Note) Validate() error {
@@ -66,26 +69,29 @@ func (nt *Note) Validate() error {
 		nt.UpdatedAt = nt.CreatedAt
 	}
 
-	tagSet := map[string]string{}
-	for _, tag := range nt.Tags {
-		tag = strings.TrimSpace(tag)
-		if tag != "" {
-			k, v := splitTag(tag)
-			tagSet[k] = v
+	// a key can have multiple values (e.g., 'env:prod' and 'env:stg'), only
+	// exact duplicates are removed.
+	tags := nt.Tags
+	seen := map[string]struct{}{}
+	nt.Tags = nil
+	for _, tag := range tags {
+		k, v := splitTag(tag)
+		if k = strings.Trim(strings.TrimSpace(k), "/"); k == "" {
+			continue
+		} else if v = strings.TrimSpace(v); v != "" {
+			k = fmt.Sprintf("%s:%s", k, v)
 		}
-	}
 
-	nt.Tags = nil
-	for k, v := range tagSet {
-		if v == "" {
+		if _, found := seen[k]; !found {
+			seen[k] = struct{}{}
 			nt.Tags = append(nt.Tags, k)
-		} else {
-			nt.Tags = append(nt.Tags, fmt.Sprintf("%s:%s", k, v))
 		}
 	}
 
 	if !nameExp.MatchString(nt.Name) {
 		return fmt.Errorf("invalid name: '%s'", nt.Name)
+	} else if strings.Contains(nt.Name, "//") || strings.HasSuffix(nt.Name, "/") {
+		return fmt.Errorf("invalid name: '%s' (empty notebook name)", nt.Name)
 	}
 	return nil
 }
@@ -95,6 +101,7 @@ func (nt *Note) Validate() error {
 func (nt *Note) ToMarkdown() []byte {
 	content := nt.Content
 	nt.Content = ""
+	defer func() { nt.Content = content }()
 
 	var buf bytes.Buffer
 	buf.WriteString("---\n")
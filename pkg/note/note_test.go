@@ -66,3 +66,15 @@ updated_at: 2022-02-14T09:54:55+05:30
 Some notes
 `
 )
+
+func TestNote_Validate_Tags(t *testing.T) {
+	n := Note{Name: "foo", Tags: []string{"env:prod", " env : stg ", "env:prod", "urgent", "", "urgent"}}
+	if err := n.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+
+	want := []string{"env:prod", "env:stg", "urgent"}
+	if !reflect.DeepEqual(n.Tags, want) {
+		t.Errorf("Validate() tags = %v, want %v", n.Tags, want)
+	}
+}
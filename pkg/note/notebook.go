@@ -0,0 +1,92 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Notebook represents a group of notes. Notebooks are formed by the slash
+// separated prefixes of note names (e.g., note 'team/oncall' is in notebook
+// 'team') and are stored as directories.
+type Notebook struct {
+	Name      string      `json:"name" yaml:"name"`
+	Path      string      `json:"path" yaml:"path"`
+	Count     int         `json:"count" yaml:"count"`
+	Notes     []string    `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Notebooks []*Notebook `json:"notebooks,omitempty" yaml:"notebooks,omitempty"`
+}
+
+// Tree returns the hierarchy of notebooks starting at the given notebook path.
+// Empty path returns the hierarchy of the entire profile. Count of a notebook
+// includes notes in all the descendant notebooks.
+func (api *API) Tree(path string) (*Notebook, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+
+	root := &Notebook{Name: api.profile, Path: path}
+	if path != "" {
+		root.Name = path[strings.LastIndex(path, "/")+1:]
+	}
+
+	for name := range api.idx {
+		if !inNotebook(name, path) {
+			continue
+		}
+
+		rel := name
+		if path != "" {
+			rel = strings.TrimPrefix(name, path+"/")
+		}
+
+		nb := root
+		nb.Count++
+
+		parts := strings.Split(rel, "/")
+		for _, part := range parts[:len(parts)-1] {
+			nb = nb.child(part)
+			nb.Count++
+		}
+		nb.Notes = append(nb.Notes, name)
+	}
+
+	if path != "" && root.Count == 0 {
+		return nil, fmt.Errorf("%w: notebook '%s'", ErrNotFound, path)
+	}
+
+	root.sort()
+	return root, nil
+}
+
+func (nb *Notebook) child(name string) *Notebook {
+	for _, c := range nb.Notebooks {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	path := name
+	if nb.Path != "" {
+		path = nb.Path + "/" + name
+	}
+
+	c := &Notebook{Name: name, Path: path}
+	nb.Notebooks = append(nb.Notebooks, c)
+	return c
+}
+
+func (nb *Notebook) sort() {
+	sort.Strings(nb.Notes)
+	sort.Slice(nb.Notebooks, func(i, j int) bool {
+		return nb.Notebooks[i].Name < nb.Notebooks[j].Name
+	})
+	for _, c := range nb.Notebooks {
+		c.sort()
+	}
+}
+
+// inNotebook returns true if the note is in the notebook or in any of its
+// descendants. Every note is in the empty notebook.
+func inNotebook(name, notebook string) bool {
+	notebook = strings.Trim(strings.TrimSpace(notebook), "/")
+	return notebook == "" || strings.HasPrefix(name, notebook+"/")
+}
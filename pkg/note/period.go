@@ -0,0 +1,318 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of periodic notes, from the shortest period to the longest.
+const (
+	PeriodDay     = "day"
+	PeriodWeek    = "week"
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// PeriodKinds has the kinds of periodic notes, shortest period first.
+var PeriodKinds = []string{PeriodDay, PeriodWeek, PeriodMonth, PeriodQuarter, PeriodYear}
+
+// DefaultPeriodFormats are the formats of periodic note names. A format is a
+// Go time layout (e.g., 'Jan-2006') where '{Y}', '{W}' and '{Q}' are replaced
+// by the ISO year, ISO week (2 digits) and quarter respectively.
+var DefaultPeriodFormats = map[string]string{
+	PeriodDay:     "day:2-Jan-2006",
+	PeriodWeek:    "week:{Y}-W{W}",
+	PeriodMonth:   "month:Jan-2006",
+	PeriodQuarter: "quarter:2006-Q{Q}",
+	PeriodYear:    "year:2006",
+}
+
+var (
+	periodTokenExp = regexp.MustCompile(`\{[YWQ]\}`)
+	periodSpecExp  = regexp.MustCompile(`^(day|week|month|quarter|year)([+-]\d+)?$`)
+	periodExps     sync.Map // format -> *regexp.Regexp matching names
+)
+
+// PeriodNav has the periodic notes related to a periodic note. Prev and Next
+// are names of the adjacent periods' notes, which may not exist. Parents has
+// the existing notes of longer periods containing the start of this period
+// and Days has the existing day notes within the period.
+type PeriodNav struct {
+	Name    string    `json:"name" yaml:"name"`
+	Kind    string    `json:"kind" yaml:"kind"`
+	Start   time.Time `json:"start" yaml:"start"`
+	End     time.Time `json:"end" yaml:"end"`
+	Prev    string    `json:"prev" yaml:"prev"`
+	Next    string    `json:"next" yaml:"next"`
+	Parents []string  `json:"parents,omitempty" yaml:"parents,omitempty"`
+	Days    []string  `json:"days,omitempty" yaml:"days,omitempty"`
+}
+
+// WithPeriodFormats overrides the formats of periodic note names. Kinds not
+// in the map use the default format. Open fails if a format does not produce
+// valid and distinct names for every period.
+func WithPeriodFormats(formats map[string]string) Option {
+	return func(api *API) error {
+		updated := copyFormats(DefaultPeriodFormats)
+		for kind, format := range formats {
+			if _, found := updated[kind]; !found {
+				return fmt.Errorf("unknown periodic note kind '%s'", kind)
+			} else if format = strings.TrimSpace(format); format != "" {
+				updated[kind] = format
+			}
+		}
+
+		for _, kind := range PeriodKinds {
+			if err := checkPeriodFormat(kind, updated[kind]); err != nil {
+				return err
+			}
+		}
+		api.periodFormats = updated
+		return nil
+	}
+}
+
+// PeriodNoteName returns the name of the periodic note of the kind for the
+// period containing the given time (e.g., 'week:2022-W41').
+func (api *API) PeriodNoteName(kind string, t time.Time) string {
+	return formatPeriod(api.periodFormats[kind], t)
+}
+
+// ParsePeriodNoteName returns the kind and the start of the period of the
+// periodic note with given name. Returns false if the name is not of a
+// periodic note.
+func (api *API) ParsePeriodNoteName(name string) (string, time.Time, bool) {
+	for _, kind := range PeriodKinds {
+		if start, ok := parsePeriod(kind, api.periodFormats[kind], name); ok {
+			return kind, start, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// ParsePeriodSpec parses periodic note specs like 'week', 'month-1' or
+// 'year+1' (relative to now) and returns the kind and a time within the
+// period. Other specs are parsed as days using ParseTime.
+func ParsePeriodSpec(spec string) (string, time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	m := periodSpecExp.FindStringSubmatch(spec)
+	if m == nil {
+		t, err := ParseTime(spec)
+		return PeriodDay, t, err
+	}
+
+	var offset int
+	if m[2] != "" {
+		offset, _ = strconv.Atoi(m[2])
+	}
+	return m[1], addPeriods(m[1], time.Now(), offset), nil
+}
+
+// PeriodStart returns the start of the period of the kind that contains t.
+func PeriodStart(kind string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch kind {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	case PeriodQuarter:
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, time.Local)
+	case PeriodYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	}
+	return day
+}
+
+// PeriodNav returns the periodic notes related to the periodic note with
+// given name. The note itself need not exist.
+func (api *API) PeriodNav(name string) (*PeriodNav, error) {
+	name = strings.TrimSpace(name)
+	kind, start, ok := api.ParsePeriodNoteName(name)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a periodic note", name)
+	}
+
+	nav := &PeriodNav{
+		Name:  name,
+		Kind:  kind,
+		Start: start,
+		End:   addPeriods(kind, start, 1),
+		Prev:  api.PeriodNoteName(kind, addPeriods(kind, start, -1)),
+		Next:  api.PeriodNoteName(kind, addPeriods(kind, start, 1)),
+	}
+
+	longer := false
+	for _, k := range PeriodKinds {
+		if k == kind {
+			longer = true
+			continue
+		} else if !longer {
+			continue
+		}
+
+		if parent := api.PeriodNoteName(k, start); api.exists(parent) {
+			nav.Parents = append(nav.Parents, parent)
+		}
+	}
+
+	if kind != PeriodDay {
+		for n := range api.idx {
+			if day, ok := api.ParseDayNoteName(n); ok && !day.Before(nav.Start) && day.Before(nav.End) {
+				nav.Days = append(nav.Days, n)
+			}
+		}
+		sort.Slice(nav.Days, func(i, j int) bool {
+			a, _ := api.ParseDayNoteName(nav.Days[i])
+			b, _ := api.ParseDayNoteName(nav.Days[j])
+			return a.Before(b)
+		})
+	}
+	return nav, nil
+}
+
+func (api *API) exists(name string) bool {
+	_, found := api.idx[name]
+	return found
+}
+
+func addPeriods(kind string, t time.Time, n int) time.Time {
+	start := PeriodStart(kind, t)
+	switch kind {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonth:
+		return start.AddDate(0, n, 0)
+	case PeriodQuarter:
+		return start.AddDate(0, 3*n, 0)
+	case PeriodYear:
+		return start.AddDate(n, 0, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+func formatPeriod(format string, t time.Time) string {
+	year, week := t.ISOWeek()
+	quarter := (int(t.Month())-1)/3 + 1
+
+	var sb strings.Builder
+	last := 0
+	for _, loc := range periodTokenExp.FindAllStringIndex(format, -1) {
+		sb.WriteString(t.Format(format[last:loc[0]]))
+		switch format[loc[0]+1] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(year))
+		case 'W':
+			sb.WriteString(fmt.Sprintf("%02d", week))
+		case 'Q':
+			sb.WriteString(strconv.Itoa(quarter))
+		}
+		last = loc[1]
+	}
+	sb.WriteString(t.Format(format[last:]))
+	return sb.String()
+}
+
+// parsePeriod parses the name as per the format and returns the start of
+// the period. Names that do not format back to the same name are rejected.
+func parsePeriod(kind, format, name string) (time.Time, bool) {
+	tokens := periodTokenExp.FindAllString(format, -1)
+	layouts := periodTokenExp.Split(format, -1)
+
+	exp, found := periodExps.Load(format)
+	if !found {
+		pattern := "^"
+		for i, layout := range layouts {
+			if layout != "" {
+				pattern += "(.+?)"
+			}
+			if i < len(tokens) {
+				pattern += `(\d+)`
+			}
+		}
+		exp, _ = periodExps.LoadOrStore(format, regexp.MustCompile(pattern+"$"))
+	}
+
+	m := exp.(*regexp.Regexp).FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	var layout, value string
+	values := map[string]int{}
+	idx := 1
+	for i, l := range layouts {
+		if l != "" {
+			layout += l + "\x00"
+			value += m[idx] + "\x00"
+			idx++
+		}
+		if i < len(tokens) {
+			values[tokens[i]], _ = strconv.Atoi(m[idx])
+			idx++
+		}
+	}
+
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if week, found := values["{W}"]; found {
+		year := t.Year()
+		if y, found := values["{Y}"]; found {
+			year = y
+		}
+		jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.Local)
+		t = PeriodStart(PeriodWeek, jan4).AddDate(0, 0, 7*(week-1))
+	} else if q, found := values["{Q}"]; found {
+		t = time.Date(t.Year(), time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, time.Local)
+	} else if y, found := values["{Y}"]; found {
+		t = time.Date(y, t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+
+	start := PeriodStart(kind, t)
+	if formatPeriod(format, start) != name {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// checkPeriodFormat verifies that names in the format are valid note names,
+// distinct for adjacent periods and can be parsed back.
+func checkPeriodFormat(kind, format string) error {
+	// samples include weeks where ISO and calendar years differ.
+	samples := []time.Time{
+		time.Date(2020, 12, 21, 0, 0, 0, 0, time.Local),
+		time.Date(2025, 12, 22, 0, 0, 0, 0, time.Local),
+	}
+	for _, t := range samples {
+		for i := 0; i < 8; i++ {
+			start := PeriodStart(kind, t)
+			name := formatPeriod(format, start)
+			if !nameExp.MatchString(name) {
+				return fmt.Errorf("format '%s' of %s notes gives invalid name '%s'", format, kind, name)
+			} else if next := formatPeriod(format, addPeriods(kind, start, 1)); next == name {
+				return fmt.Errorf("format '%s' of %s notes gives same name for adjacent periods", format, kind)
+			} else if parsed, ok := parsePeriod(kind, format, name); !ok || !parsed.Equal(start) {
+				return fmt.Errorf("format '%s' of %s notes cannot be parsed back from '%s'", format, kind, name)
+			}
+			t = addPeriods(kind, t, 1)
+		}
+	}
+	return nil
+}
+
+func copyFormats(formats map[string]string) map[string]string {
+	res := make(map[string]string, len(formats))
+	for k, v := range formats {
+		res[k] = v
+	}
+	return res
+}
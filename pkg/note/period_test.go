@@ -0,0 +1,163 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPI_PeriodNoteName(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		kind string
+		t    time.Time
+		want string
+	}{
+		{PeriodDay, time.Date(2022, 10, 16, 10, 0, 0, 0, time.Local), "day:16-Oct-2022"},
+		{PeriodWeek, time.Date(2022, 10, 16, 10, 0, 0, 0, time.Local), "week:2022-W41"},
+		{PeriodWeek, time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local), "week:2020-W53"},
+		{PeriodMonth, time.Date(2022, 10, 16, 0, 0, 0, 0, time.Local), "month:Oct-2022"},
+		{PeriodQuarter, time.Date(2022, 10, 16, 0, 0, 0, 0, time.Local), "quarter:2022-Q4"},
+		{PeriodYear, time.Date(2022, 10, 16, 0, 0, 0, 0, time.Local), "year:2022"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			name := api.PeriodNoteName(tt.kind, tt.t)
+			if name != tt.want {
+				t.Fatalf("api.PeriodNoteName() got %q, want %q", name, tt.want)
+			}
+
+			kind, start, ok := api.ParsePeriodNoteName(name)
+			if !ok || kind != tt.kind || !start.Equal(PeriodStart(tt.kind, tt.t)) {
+				t.Errorf("api.ParsePeriodNoteName() got (%q, %v, %t), want (%q, %v, true)",
+					kind, start, ok, tt.kind, PeriodStart(tt.kind, tt.t))
+			}
+		})
+	}
+
+	for _, name := range []string{"week:2022-W1", "week:2022-W54", "month:Foo-2022", "quarter:2022-Q5", "kafka"} {
+		if _, _, ok := api.ParsePeriodNoteName(name); ok {
+			t.Errorf("api.ParsePeriodNoteName(%q) expected false", name)
+		}
+	}
+}
+
+func TestWithPeriodFormats(t *testing.T) {
+	for _, format := range []string{"week:2006", "W{W}", "week {Y}-{W}"} {
+		if _, err := Open("test", NewMemStore(), nopLog, WithPeriodFormats(map[string]string{PeriodWeek: format})); err == nil {
+			t.Errorf("WithPeriodFormats(%q) expected error", format)
+		}
+	}
+	if _, err := Open("test", NewMemStore(), nopLog, WithPeriodFormats(map[string]string{"decade": "decade:2006"})); err == nil {
+		t.Errorf("WithPeriodFormats() expected error for unknown kind")
+	}
+
+	formats := map[string]string{PeriodWeek: "journal/{Y}/week-{W}", PeriodDay: "journal/2006-01-02"}
+	api, err := Open("journal", NewMemStore(), nopLog, WithPeriodFormats(formats))
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	other, err := Open("other", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	d := time.Date(2022, 10, 16, 0, 0, 0, 0, time.Local)
+	if got := api.PeriodNoteName(PeriodWeek, d); got != "journal/2022/week-41" {
+		t.Errorf("PeriodNoteName() got %q, want 'journal/2022/week-41'", got)
+	}
+	if got := api.DayNoteName(d); got != "journal/2022-10-16" {
+		t.Errorf("DayNoteName() got %q, want 'journal/2022-10-16'", got)
+	}
+	if day, ok := api.ParseDayNoteName("journal/2022-10-16"); !ok || !day.Equal(d) {
+		t.Errorf("ParseDayNoteName() got (%v, %t), want (%v, true)", day, ok, d)
+	}
+
+	// formats of one API must not affect others in the process.
+	if got := other.DayNoteName(d); got != "day:16-Oct-2022" {
+		t.Errorf("DayNoteName() of other API got %q, want 'day:16-Oct-2022'", got)
+	}
+	if _, ok := other.ParseDayNoteName("journal/2022-10-16"); ok {
+		t.Errorf("ParseDayNoteName() of other API expected false")
+	}
+}
+
+func TestParsePeriodSpec(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		spec string
+		kind string
+		want time.Time
+	}{
+		{"week", PeriodWeek, PeriodStart(PeriodWeek, now)},
+		{"month-1", PeriodMonth, PeriodStart(PeriodMonth, now).AddDate(0, -1, 0)},
+		{"year+1", PeriodYear, PeriodStart(PeriodYear, now).AddDate(1, 0, 0)},
+		{"-1", PeriodDay, PeriodStart(PeriodDay, now.AddDate(0, 0, -1))},
+	}
+	for _, tt := range tests {
+		kind, got, err := ParsePeriodSpec(tt.spec)
+		if err != nil {
+			t.Fatalf("ParsePeriodSpec(%q) unexpected error: %v", tt.spec, err)
+		} else if kind != tt.kind || !PeriodStart(kind, got).Equal(tt.want) {
+			t.Errorf("ParsePeriodSpec(%q) got (%q, %v), want (%q, %v)", tt.spec, kind, got, tt.kind, tt.want)
+		}
+	}
+
+	if _, _, err := ParsePeriodSpec("fortnight"); err == nil {
+		t.Errorf("ParsePeriodSpec() expected error for unknown spec")
+	}
+}
+
+func TestAPI_PeriodNav(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	mon := time.Date(2022, 10, 10, 0, 0, 0, 0, time.Local)
+	names := []string{
+		api.DayNoteName(mon.AddDate(0, 0, 2)),
+		api.DayNoteName(mon),
+		api.DayNoteName(mon.AddDate(0, 0, 7)),
+		api.PeriodNoteName(PeriodWeek, mon),
+		api.PeriodNoteName(PeriodYear, mon),
+	}
+	for _, name := range names {
+		if _, err := api.Put(Note{Name: name}, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	nav, err := api.PeriodNav("week:2022-W41")
+	if err != nil {
+		t.Fatalf("PeriodNav() unexpected error: %v", err)
+	}
+	want := &PeriodNav{
+		Name:    "week:2022-W41",
+		Kind:    PeriodWeek,
+		Start:   mon,
+		End:     mon.AddDate(0, 0, 7),
+		Prev:    "week:2022-W40",
+		Next:    "week:2022-W42",
+		Parents: []string{"year:2022"},
+		Days:    []string{"day:10-Oct-2022", "day:12-Oct-2022"},
+	}
+	if !reflect.DeepEqual(nav, want) {
+		t.Errorf("PeriodNav() got %+v, want %+v", nav, want)
+	}
+
+	nav, err = api.PeriodNav("day:12-Oct-2022")
+	if err != nil {
+		t.Fatalf("PeriodNav() unexpected error: %v", err)
+	} else if !reflect.DeepEqual(nav.Parents, []string{"week:2022-W41", "year:2022"}) || nav.Days != nil {
+		t.Errorf("PeriodNav() got parents %v and days %v, want week and year notes only", nav.Parents, nav.Days)
+	}
+
+	if _, err := api.PeriodNav("kafka"); err == nil {
+		t.Errorf("PeriodNav() expected error for non-periodic note")
+	}
+}
@@ -0,0 +1,554 @@
+package note
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tagFilterWordExp matches words that are tag filters (e.g., 'priority>=2').
+var tagFilterWordExp = regexp.MustCompile(`^[\p{L}\p{N}_./-]+(>=|<=|!=|=|>|<)[^\s=<>!]+$`)
+
+// queryFields are the fields of 'field:value' terms. Words with any other
+// prefix (e.g., '10:30' or 'http://host') are text terms.
+var queryFields = arrToSet([]string{"tag", "tags", "notebook", "in", "text", "name", "created", "updated"})
+
+// SyntaxError is returned by ParseQuery for malformed queries. Pos is the
+// 1-based position of the offending character in the query.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Expr is a boolean expression over notes. Use ParseQuery to create one.
+type Expr interface {
+	match(name string, node indexNode) bool
+	String() string
+}
+
+// ParseQuery parses the query string into a Query. Terms are separated by
+// whitespace and are combined with AND unless 'OR' is used between them.
+// 'NOT' or '-' negates a term, parentheses group terms. Supported terms:
+//
+//	word, "some phrase"      note content contains the word or phrase
+//	tag:oncall               note has the tag (or a descendant tag)
+//	priority>=2, project=*   note has a key:value tag matching the filter
+//	env in (prod, stg)       (see ParseTagFilter)
+//	name:kafka, name:/^k.*/  name contains the text or matches the regex
+//	notebook:team            note is in the notebook (or its descendants)
+//	created:>2022-01-01      created after the day (also <, >=, <=)
+//	created:2022-01-01       created on the day
+//	created:2022-01-01..2022-02-01
+//	updated:<7d              updated within last 7 days (>7d for older)
+//
+// Dates can be in 2006-01-02 format or any format supported by ParseTime.
+func ParseQuery(s string) (Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+
+	p := &queryParser{src: s, toks: toks}
+	if len(toks) == 0 {
+		return Query{}, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return Query{}, err
+	} else if p.pos < len(p.toks) {
+		tok := p.toks[p.pos]
+		return Query{}, p.errorf(tok.pos, "unexpected '%s'", tok.text)
+	}
+	return Query{Expr: expr}, nil
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+	pos  int // 0-based byte offset in the query.
+}
+
+func lexQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+
+		case c == '(':
+			toks = append(toks, queryToken{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			toks = append(toks, queryToken{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case c == '-' && i+1 < len(s) && !isSpaceAt(s, i+1):
+			toks = append(toks, queryToken{kind: tokNot, text: "-", pos: i})
+			i++
+
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, newSyntaxError(s, i, "unterminated quote")
+			}
+			toks = append(toks, queryToken{kind: tokPhrase, text: s[i+1 : i+1+end], pos: i})
+			i += end + 2
+
+		default:
+			start := i
+			var word strings.Builder
+			for i < len(s) && !isSpaceAt(s, i) && s[i] != '(' && s[i] != ')' {
+				if s[i] == ':' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '"') {
+					// quoted value or regex may contain spaces and parens.
+					quote := s[i+1]
+					end := strings.IndexByte(s[i+2:], quote)
+					for quote == '/' && end > 0 && s[i+1+end] == '\\' {
+						next := strings.IndexByte(s[i+2+end+1:], quote)
+						if next < 0 {
+							end = -1
+							break
+						}
+						end += next + 1
+					}
+					if end < 0 {
+						return nil, newSyntaxError(s, i+1, fmt.Sprintf("unterminated '%c'", quote))
+					}
+
+					word.WriteByte(':')
+					if quote == '/' {
+						word.WriteString(s[i+1 : i+3+end])
+					} else {
+						word.WriteString(s[i+2 : i+2+end])
+					}
+					i += end + 3
+					continue
+				}
+				_, size := utf8.DecodeRuneInString(s[i:])
+				word.WriteString(s[i : i+size])
+				i += size
+			}
+
+			kind := tokWord
+			switch word.String() {
+			case "AND", "&&":
+				kind = tokAnd
+			case "OR", "||":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			toks = append(toks, queryToken{kind: kind, text: word.String(), pos: start})
+		}
+	}
+	return toks, nil
+}
+
+func isSpaceAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsSpace(r)
+}
+
+// newSyntaxError returns a SyntaxError for the byte offset in the query.
+func newSyntaxError(src string, offset int, msg string) *SyntaxError {
+	return &SyntaxError{Pos: utf8.RuneCountInString(src[:offset]) + 1, Msg: msg}
+}
+
+type queryParser struct {
+	src  string
+	toks []queryToken
+	pos  int
+}
+
+func (p *queryParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := orExpr{left}
+	for p.peek(tokOr) {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *queryParser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := andExpr{left}
+	for p.pos < len(p.toks) {
+		if p.peek(tokAnd) {
+			p.pos++
+		} else if p.peek(tokOr) || p.peek(tokRParen) {
+			break
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *queryParser) parseUnary() (Expr, error) {
+	if p.peek(tokNot) {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (Expr, error) {
+	if p.pos >= len(p.toks) {
+		return nil, p.errorf(len(p.src), "unexpected end of query")
+	}
+
+	tok := p.toks[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		} else if !p.peek(tokRParen) {
+			return nil, p.errorf(tok.pos, "missing ')' for '('")
+		}
+		p.pos++
+		return expr, nil
+
+	case tokPhrase:
+		return newTextExpr(tok.text), nil
+
+	case tokWord:
+		return p.parseTerm(tok)
+
+	default:
+		return nil, p.errorf(tok.pos, "unexpected '%s'", tok.text)
+	}
+}
+
+func (p *queryParser) parseTerm(tok queryToken) (Expr, error) {
+	idx := strings.IndexByte(tok.text, ':')
+	if tagFilterWordExp.MatchString(tok.text) {
+		f, err := ParseTagFilter(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "%v", err)
+		}
+		return tagFilterExpr{f}, nil
+	} else if idx < 0 {
+		if p.peekWord("in") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == tokLParen {
+			return p.parseInFilter(tok)
+		}
+		return newTextExpr(tok.text), nil
+	}
+
+	field, value := strings.ToLower(tok.text[:idx]), tok.text[idx+1:]
+	valuePos := tok.pos + idx + 1
+	if _, found := queryFields[field]; !found {
+		return newTextExpr(tok.text), nil
+	} else if value == "" {
+		return nil, p.errorf(valuePos, "missing value for '%s'", field)
+	}
+
+	switch field {
+	case "tag", "tags":
+		return tagExpr(value), nil
+
+	case "notebook", "in":
+		return notebookExpr(strings.Trim(value, "/")), nil
+
+	case "text":
+		return newTextExpr(value), nil
+
+	case "name":
+		pattern := "(?i)" + regexp.QuoteMeta(value)
+		if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			pattern = value[1 : len(value)-1]
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf(valuePos, "invalid regex: %v", err)
+		}
+		return nameExpr{re}, nil
+
+	default: // created or updated
+		from, to, err := parseTimeRange(value)
+		if err != nil {
+			return nil, p.errorf(valuePos, "%v", err)
+		}
+		return timeExpr{field: field, from: from, to: to, raw: value}, nil
+	}
+}
+
+// parseInFilter parses the rest of 'key in (a, b)' after the key.
+func (p *queryParser) parseInFilter(key queryToken) (Expr, error) {
+	lparen := p.toks[p.pos+1]
+	p.pos += 2
+
+	var values []string
+	for !p.peek(tokRParen) {
+		if p.pos >= len(p.toks) {
+			return nil, p.errorf(lparen.pos, "missing ')' for '('")
+		}
+
+		tok := p.toks[p.pos]
+		if tok.kind != tokWord && tok.kind != tokPhrase {
+			return nil, p.errorf(tok.pos, "unexpected '%s' in list", tok.text)
+		}
+		values = append(values, tok.text)
+		p.pos++
+	}
+	p.pos++
+
+	f, err := ParseTagFilter(fmt.Sprintf("%s in (%s)", key.text, strings.Join(values, ",")))
+	if err != nil {
+		return nil, p.errorf(key.pos, "%v", err)
+	}
+	return tagFilterExpr{f}, nil
+}
+
+func (p *queryParser) peekWord(word string) bool {
+	return p.peek(tokWord) && strings.EqualFold(p.toks[p.pos].text, word)
+}
+
+func (p *queryParser) peek(kind tokenKind) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == kind
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...interface{}) error {
+	return newSyntaxError(p.src, pos, fmt.Sprintf(format, args...))
+}
+
+// parseTimeRange parses a time condition into an inclusive range of unix
+// timestamps.
+func parseTimeRange(value string) (from, to int64, err error) {
+	from, to = math.MinInt64, math.MaxInt64
+
+	if parts := strings.SplitN(value, "..", 2); len(parts) == 2 {
+		start, _, err := parseDay(parts[0])
+		if err != nil {
+			return 0, 0, err
+		}
+		_, end, err := parseDay(parts[1])
+		if err != nil {
+			return 0, 0, err
+		}
+		return start.Unix(), end.Unix(), nil
+	}
+
+	var op string
+	for _, candidate := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, candidate) {
+			op, value = candidate, strings.TrimPrefix(value, candidate)
+			break
+		}
+	}
+
+	// relative durations are ages, '<7d' means less than 7 days old.
+	if d, err := ParseDuration(value); err == nil && op != "" {
+		t := time.Now().Add(-d).Unix()
+		if op[0] == '<' {
+			return t, to, nil
+		}
+		return from, t, nil
+	}
+
+	start, end, err := parseDay(value)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch op {
+	case ">":
+		return end.Unix() + 1, to, nil
+	case ">=":
+		return start.Unix(), to, nil
+	case "<":
+		return from, start.Unix() - 1, nil
+	case "<=":
+		return from, end.Unix(), nil
+	default:
+		return start.Unix(), end.Unix(), nil
+	}
+}
+
+// parseDay parses the date and returns the first and last second of the day.
+func parseDay(s string) (start, end time.Time, err error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		t, err = ParseTime(s)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid date '%s'", s)
+		}
+	}
+
+	start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	end = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+	return start, end, nil
+}
+
+type andExpr []Expr
+
+func (e andExpr) match(name string, node indexNode) bool {
+	for _, sub := range e {
+		if !sub.match(name, node) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e andExpr) String() string { return joinExprs(e, " AND ") }
+
+type orExpr []Expr
+
+func (e orExpr) match(name string, node indexNode) bool {
+	for _, sub := range e {
+		if sub.match(name, node) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e orExpr) String() string { return joinExprs(e, " OR ") }
+
+type notExpr struct{ Expr }
+
+func (e notExpr) match(name string, node indexNode) bool { return !e.Expr.match(name, node) }
+
+func (e notExpr) String() string { return "NOT " + e.Expr.String() }
+
+type tagExpr string
+
+func (e tagExpr) match(_ string, node indexNode) bool {
+	return node.hasTag(string(e))
+}
+
+func (e tagExpr) String() string { return "tag:" + string(e) }
+
+type tagFilterExpr struct{ TagFilter }
+
+func (e tagFilterExpr) match(_ string, node indexNode) bool { return e.TagFilter.match(node) }
+
+type notebookExpr string
+
+func (e notebookExpr) match(name string, _ indexNode) bool { return inNotebook(name, string(e)) }
+
+func (e notebookExpr) String() string { return "notebook:" + string(e) }
+
+type nameExpr struct{ re *regexp.Regexp }
+
+func (e nameExpr) match(name string, _ indexNode) bool { return e.re.MatchString(name) }
+
+func (e nameExpr) String() string { return "name:/" + e.re.String() + "/" }
+
+type textExpr []string
+
+func newTextExpr(text string) Expr {
+	phrases := parseText(`"` + text + `"`)
+	if len(phrases) == 0 {
+		return andExpr{} // no searchable terms, matches everything.
+	}
+	return textExpr(phrases[0])
+}
+
+func (e textExpr) match(_ string, node indexNode) bool { return hasPhrase(node.Terms, e) }
+
+func (e textExpr) String() string { return fmt.Sprintf("%q", strings.Join(e, " ")) }
+
+type timeExpr struct {
+	field    string
+	from, to int64
+	raw      string
+}
+
+func (e timeExpr) match(_ string, node indexNode) bool {
+	t := node.CreatedAt
+	if e.field == "updated" {
+		t = node.UpdatedAt
+	}
+	return t >= e.from && t <= e.to
+}
+
+func (e timeExpr) String() string { return e.field + ":" + e.raw }
+
+func joinExprs(exprs []Expr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+		if _, isGroup := e.(orExpr); isGroup {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// exprTerms returns the terms of text expressions that are not negated, used
+// for relevance ranking.
+func exprTerms(e Expr) []string {
+	switch v := e.(type) {
+	case andExpr:
+		var terms []string
+		for _, sub := range v {
+			terms = append(terms, exprTerms(sub)...)
+		}
+		return terms
+	case orExpr:
+		var terms []string
+		for _, sub := range v {
+			terms = append(terms, exprTerms(sub)...)
+		}
+		return terms
+	case textExpr:
+		return v
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package note
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{query: `tag:a (tag:b`, pos: 7},
+		{query: `tag:a )`, pos: 7},
+		{query: `tag:a OR`, pos: 9},
+		{query: `"consumer lag`, pos: 1},
+		{query: `tag:a created:>yesterday-ish`, pos: 15},
+		{query: `name:/[a/`, pos: 6},
+		{query: `name:/abc`, pos: 6},
+		{query: `tag:`, pos: 5},
+		{query: `NOT`, pos: 4},
+		{query: `tag:a env in (prod`, pos: 14},
+		{query: `voilà (tag:b`, pos: 7},
+		{query: `Åsa "x`, pos: 5},
+		{query: `café created:bogus`, pos: 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ParseQuery() expected SyntaxError, got %v", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("ParseQuery() error at %d, want %d (%v)", syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Terms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `voilà`, want: `"voilà"`},
+		{query: `Åsa kafka`, want: `"åsa" AND "kafka"`},
+		{query: `wow!`, want: `"wow"`},
+		{query: `<3`, want: `"3"`},
+		{query: `tag:a priority>=`, want: `tag:a AND "priority"`},
+		{query: `priority>=2 env=prod`, want: `priority>=2 AND env=prod`},
+		{query: `tga:x`, want: `"tga x"`},
+		{query: `10:30`, want: `"10 30"`},
+		{query: `http://host`, want: `"http host"`},
+		{query: `day:16-Oct-2026 TAG:a`, want: `"day 16 oct 2026" AND tag:a`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() unexpected error: %v", err)
+			} else if got := q.Expr.String(); got != tt.want {
+				t.Errorf("ParseQuery() got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI_Search_Query(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	old := time.Date(2021, 6, 1, 10, 0, 0, 0, time.Local)
+	notes := []Note{
+		{Name: "kafka-lag", Tags: []string{"oncall"}, Content: "Consumer lag alerts fired.", CreatedAt: old},
+		{Name: "kafka-setup", Tags: []string{"oncall", "draft"}, Content: "Consumer lag is monitored."},
+		{Name: "redis", Tags: []string{"oncall"}, Content: "Memory alerts fired."},
+		{Name: "team/kafka", Tags: []string{"meeting"}, Content: "Discussed the consumer."},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `tag:oncall -tag:draft`, want: "kafka-lag,redis"},
+		{query: `tag:oncall NOT tag:draft "consumer lag"`, want: "kafka-lag"},
+		{query: `name:/^kafka/`, want: "kafka-lag,kafka-setup"},
+		{query: `name:KAFKA`, want: "kafka-lag,kafka-setup,team/kafka"},
+		{query: `tag:meeting OR tag:draft`, want: "kafka-setup,team/kafka"},
+		{query: `(tag:meeting OR tag:draft) AND consumer`, want: "kafka-setup,team/kafka"},
+		{query: `-(tag:oncall OR tag:meeting)`, want: ""},
+		{query: `notebook:team`, want: "team/kafka"},
+		{query: `created:<2022-01-01`, want: "kafka-lag"},
+		{query: `created:2021-06-01`, want: "kafka-lag"},
+		{query: `created:2021-01-01..2021-12-31 OR name:redis`, want: "kafka-lag,redis"},
+		{query: `created:>=2022-01-01 alerts`, want: "redis"},
+		{query: `updated:<7d tag:meeting`, want: "team/kafka"},
+		{query: `updated:>7d`, want: ""},
+		{query: `name:"team/kafka"`, want: "team/kafka"},
+		{query: `alerts:fired`, want: "kafka-lag,redis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() unexpected error: %v", err)
+			}
+			q.Sort = SortName
+
+			res, err := api.Search(q, false)
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, h := range res {
+				names = append(names, h.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("Search() got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
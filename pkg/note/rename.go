@@ -0,0 +1,224 @@
+package note
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rename renames the note and rewrites the [[links]] and markdown links to it
+// in all the other notes. Creation and update times, tags and history of the
+// renamed note are retained. Returns the names of the other notes that link
+// to it and were (or, if dryRun is set, would be) rewritten.
+func (api *API) Rename(from, to string, dryRun bool) ([]string, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+
+	target := Note{Name: to, CreatedAt: time.Now()}
+	if err := target.Validate(); err != nil {
+		return nil, err
+	} else if from == to {
+		return nil, fmt.Errorf("%w: note is already named '%s'", ErrConflict, to)
+	}
+
+	referrers := func() ([]string, error) {
+		if _, found := api.idx[from]; !found {
+			return nil, fmt.Errorf("%w: note with name '%s'", ErrNotFound, from)
+		} else if _, found := api.idx[to]; found {
+			return nil, fmt.Errorf("%w: note with name '%s' already exists", ErrConflict, to)
+		}
+
+		var names []string
+		for name := range api.backlinks[from] {
+			if name != from {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	names, err := referrers()
+	if err != nil || dryRun {
+		return names, err
+	}
+
+	var updated []string
+	err = api.apply("rename", append([]string{from, to}, names...), func() error {
+		// index is re-loaded by apply and may have changed.
+		names, err := referrers()
+		if err != nil {
+			return err
+		}
+
+		n, err := api.Get(from)
+		if err != nil {
+			return err
+		}
+		n.Name = to
+		n.Content, _ = rewriteLinks(from, to, n.Content, from, to)
+
+		if err := api.store.Put(noteKey(to), n.ToMarkdown()); err != nil {
+			return err
+		} else if err := api.putNode(to, newIndexNode(*n)); err != nil {
+			return err
+		} else if err := api.moveHistory(from, to); err != nil {
+			return err
+		} else if err := api.store.Del(noteKey(from)); err != nil {
+			return err
+		}
+		api.delNode(from)
+
+		for _, name := range names {
+			ref, err := api.Get(name)
+			if err != nil {
+				return err
+			}
+
+			content, changed := rewriteLinks(name, name, ref.Content, from, to)
+			if !changed {
+				continue
+			}
+			ref.Content = content
+			ref.UpdatedAt = time.Now()
+
+			if err := api.saveRevision(*ref); err != nil {
+				return err
+			} else if err := api.store.Put(noteKey(name), ref.ToMarkdown()); err != nil {
+				return err
+			} else if err := api.putNode(name, newIndexNode(*ref)); err != nil {
+				return err
+			}
+			updated = append(updated, name)
+		}
+		return api.syncIdx()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// moveHistory moves the revisions of a note to the history of another note.
+func (api *API) moveHistory(from, to string) error {
+	revs, err := api.History(from)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revs {
+		d, err := api.store.Get(rev.key)
+		if err != nil {
+			return err
+		}
+
+		key := historyPrefix(to) + strings.TrimPrefix(rev.key, historyPrefix(from))
+		if err := api.store.Put(key, d); err != nil {
+			return err
+		} else if err := api.store.Del(rev.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteLinks rewrites links to note 'oldName' in the content of note 'src'
+// to point to 'newName'. If the note itself is being renamed to 'newSrc',
+// its relative markdown links are updated to remain valid from the new
+// location. Returns true if the content was changed.
+func rewriteLinks(src, newSrc, content, oldName, newName string) (string, bool) {
+	changed := false
+	content = forEachLine(content, func(_ int, line string) string {
+		masked := inlineCodeExp.ReplaceAllStringFunc(line, func(s string) string {
+			return strings.Repeat(" ", len(s))
+		})
+
+		type edit struct {
+			start, end int
+			text       string
+		}
+		var edits []edit
+
+		for _, m := range wikiLinkExp.FindAllStringSubmatchIndex(masked, -1) {
+			target := line[m[2]:m[3]]
+			name, section := target, ""
+			if idx := strings.IndexByte(target, '#'); idx >= 0 {
+				name, section = target[:idx], target[idx:]
+			}
+
+			if strings.TrimSpace(name) == oldName {
+				edits = append(edits, edit{start: m[2], end: m[3], text: newName + section})
+			}
+		}
+
+		for _, m := range mdLinkExp.FindAllStringSubmatchIndex(masked, -1) {
+			target := line[m[4]:m[5]]
+			l, ok := resolveLink(src, target)
+			if !ok {
+				continue
+			}
+
+			renamed := l.Kind == LinkMarkdown && l.Name == oldName
+			moved := !strings.HasPrefix(target, "/") && path.Dir(src) != path.Dir(newSrc)
+			if !renamed && !moved {
+				continue
+			}
+
+			dest := l.Name
+			if l.Kind != LinkAttachment {
+				if renamed {
+					dest = newName
+				}
+				dest += ".md"
+			}
+
+			var section string
+			if idx := strings.IndexByte(target, '#'); idx >= 0 {
+				section = target[idx:]
+			}
+
+			if strings.HasPrefix(target, "/") {
+				dest = "/" + dest
+			} else {
+				dest = relativePath(path.Dir(newSrc), dest)
+			}
+			edits = append(edits, edit{start: m[4], end: m[5], text: dest + section})
+		}
+
+		if len(edits) == 0 {
+			return line
+		}
+
+		// apply from the end so that earlier offsets remain valid.
+		sort.Slice(edits, func(a, b int) bool { return edits[a].start > edits[b].start })
+		for _, e := range edits {
+			line = line[:e.start] + e.text + line[e.end:]
+		}
+		changed = true
+		return line
+	})
+	return content, changed
+}
+
+// relativePath returns the slash separated path of target relative to the
+// directory. Both are relative to the profile root ("." for the root).
+func relativePath(dir, target string) string {
+	var dirParts []string
+	if dir != "." && dir != "" {
+		dirParts = strings.Split(dir, "/")
+	}
+	targetParts := strings.Split(target, "/")
+
+	common := 0
+	for common < len(dirParts) && common < len(targetParts)-1 && dirParts[common] == targetParts[common] {
+		common++
+	}
+
+	parts := make([]string, 0, len(dirParts)-common+len(targetParts)-common)
+	for range dirParts[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, targetParts[common:]...)
+	return strings.Join(parts, "/")
+}
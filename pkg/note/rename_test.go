@@ -0,0 +1,166 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_Rename(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	notes := []Note{
+		{Name: "kafka", Tags: []string{"infra"}, CreatedAt: created, Content: "Self [[kafka]], [up](index.md), ![img](img/lag.png)"},
+		{Name: "index", Content: "See [[kafka]], [[kafka#setup|setup]] and [Kafka](kafka.md#lag).\n```\n[[kafka]]\n```\n`[[kafka]]`"},
+		{Name: "team/oncall", Content: "[Kafka](../kafka.md) and [root](/kafka.md) but not [[kafkaesque]]"},
+		{Name: "unrelated", Content: "[[index]]"},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	// create a revision of the note being renamed.
+	if _, err := api.Put(Note{Name: "kafka", Tags: []string{"infra"}, Content: notes[0].Content + "\nmore"}, false); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	affected, err := api.Rename("kafka", "infra/kafka", true)
+	if err != nil {
+		t.Fatalf("Rename() dry-run unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(affected, []string{"index", "team/oncall"}) {
+		t.Errorf("Rename() dry-run got %v, want [index team/oncall]", affected)
+	}
+	if _, err := api.Get("kafka"); err != nil {
+		t.Fatalf("Rename() dry-run must not rename: %v", err)
+	}
+
+	affected, err = api.Rename("kafka", "infra/kafka", false)
+	if err != nil {
+		t.Fatalf("Rename() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(affected, []string{"index", "team/oncall"}) {
+		t.Errorf("Rename() got %v, want [index team/oncall]", affected)
+	}
+
+	if _, err := api.Get("kafka"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() old name expected ErrNotFound, got %v", err)
+	}
+
+	renamed, err := api.Get("infra/kafka")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if !renamed.CreatedAt.Equal(created) || !reflect.DeepEqual(renamed.Tags, []string{"infra"}) {
+		t.Errorf("Rename() did not keep metadata: %+v", renamed)
+	}
+	wantContent := "Self [[infra/kafka]], [up](../index.md), ![img](../img/lag.png)\nmore"
+	if renamed.Content != wantContent {
+		t.Errorf("Rename() content got %q, want %q", renamed.Content, wantContent)
+	}
+	if revs, err := api.History("infra/kafka"); err != nil || len(revs) != 1 {
+		t.Errorf("History() got %d revisions (err=%v), want 1", len(revs), err)
+	}
+
+	wants := map[string]string{
+		"index":       "See [[infra/kafka]], [[infra/kafka#setup|setup]] and [Kafka](infra/kafka.md#lag).\n```\n[[kafka]]\n```\n`[[kafka]]`",
+		"team/oncall": "[Kafka](../infra/kafka.md) and [root](/infra/kafka.md) but not [[kafkaesque]]",
+		"unrelated":   "[[index]]",
+	}
+	for name, want := range wants {
+		n, err := api.Get(name)
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if n.Content != want {
+			t.Errorf("Rename() content of '%s' got %q, want %q", name, n.Content, want)
+		}
+	}
+
+	if got, _ := api.Backlinks("infra/kafka"); !reflect.DeepEqual(got, []string{"index", "infra/kafka", "team/oncall"}) {
+		t.Errorf("Backlinks() got %v", got)
+	}
+
+	// index must be consistent with the store after re-opening.
+	api, err = Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	res, err := api.Search(Query{Sort: SortName}, false)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if got := hitNames(res); got != "index,infra/kafka,team/oncall,unrelated" {
+		t.Errorf("Search() got %s", got)
+	}
+
+	if _, err := api.Rename("index", "unrelated", false); !errors.Is(err, ErrConflict) {
+		t.Errorf("Rename() expected ErrConflict, got %v", err)
+	}
+	if _, err := api.Rename("missing", "other", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rename() expected ErrNotFound, got %v", err)
+	}
+	if _, err := api.Rename("index", "bad name", false); err == nil || strings.Contains(err.Error(), "not found") {
+		t.Errorf("Rename() expected invalid name error, got %v", err)
+	}
+}
+
+func TestAPI_Rename_HandWritten(t *testing.T) {
+	store := NewMemStore()
+	_ = store.Put("kafka.md", []byte("# Kafka"))
+
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	before, err := api.Search(Query{}, false)
+	if err != nil || len(before) != 1 {
+		t.Fatalf("Search() got %v (err=%v), want one note", before, err)
+	}
+
+	if _, err := api.Rename("kafka", "infra/kafka", false); err != nil {
+		t.Fatalf("Rename() unexpected error: %v", err)
+	}
+
+	n, err := Parse(mustGet(t, store, "infra/kafka.md"))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	} else if n.CreatedAt.IsZero() || n.UpdatedAt.IsZero() {
+		t.Errorf("Rename() saved zero timestamps: created %v, updated %v", n.CreatedAt, n.UpdatedAt)
+	}
+
+	after, err := api.Search(Query{}, false)
+	if err != nil || len(after) != 1 {
+		t.Fatalf("Search() got %v (err=%v), want one note", after, err)
+	} else if !after[0].CreatedAt.Equal(before[0].CreatedAt) || !after[0].UpdatedAt.Equal(before[0].UpdatedAt) {
+		t.Errorf("Rename() changed timestamps from %v/%v to %v/%v", before[0].CreatedAt, before[0].UpdatedAt,
+			after[0].CreatedAt, after[0].UpdatedAt)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		dir, target, want string
+	}{
+		{dir: ".", target: "kafka.md", want: "kafka.md"},
+		{dir: "team", target: "kafka.md", want: "../kafka.md"},
+		{dir: "team", target: "team/kafka.md", want: "kafka.md"},
+		{dir: "team/a", target: "team/b/kafka.md", want: "../b/kafka.md"},
+		{dir: ".", target: "team/kafka.md", want: "team/kafka.md"},
+		{dir: "team", target: "team.md", want: "../team.md"},
+	}
+	for _, tt := range tests {
+		if got := relativePath(tt.dir, tt.target); got != tt.want {
+			t.Errorf("relativePath(%s, %s) got %s, want %s", tt.dir, tt.target, got, tt.want)
+		}
+	}
+}
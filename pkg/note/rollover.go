@@ -0,0 +1,94 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var uncheckedExp = regexp.MustCompile(`^(\s*[-*+] )\[ \]( .*)$`)
+
+// UncheckedItems returns the unchecked checklist items ('- [ ] ...') in the
+// note with given name. Nested items keep their indentation relative to the
+// least indented item.
+func (api *API) UncheckedItems(name string) ([]string, error) {
+	n, err := api.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return uncheckedItems(n.Content), nil
+}
+
+// Rollover creates the note with the unchecked items of the note 'from'
+// appended to its content. If markMigrated is set, the items are marked as
+// migrated ('- [>] ...') in the note 'from'. Both notes are saved in a single
+// operation. Returns the created note and the number of items carried over.
+func (api *API) Rollover(n Note, from string, markMigrated bool) (*Note, int, error) {
+	from = strings.TrimSpace(from)
+	hasCreatedAt := !n.CreatedAt.IsZero()
+	if err := n.Validate(); err != nil {
+		return nil, 0, err
+	}
+	n.UpdatedAt = time.Now()
+
+	var count int
+	err := api.apply("rollover", []string{n.Name, from}, func() error {
+		prev, err := api.Get(from)
+		if err != nil {
+			return err
+		}
+
+		items := uncheckedItems(prev.Content)
+		count = len(items)
+		if count > 0 {
+			n.Content = strings.TrimRight(n.Content, "\n") +
+				fmt.Sprintf("\n\n## Carried over from [[%s]]\n\n%s", from, strings.Join(items, "\n"))
+		}
+
+		if err := api.put(&n, true, hasCreatedAt); err != nil {
+			return err
+		}
+
+		if markMigrated && count > 0 {
+			prev.Name = from
+			prev.Content = markMigratedItems(prev.Content)
+			prev.UpdatedAt = time.Now()
+			if err := api.put(prev, false, !prev.CreatedAt.IsZero()); err != nil {
+				return err
+			}
+		}
+		return api.syncIdx()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &n, count, nil
+}
+
+func uncheckedItems(content string) []string {
+	var items []string
+	indent := -1
+	forEachLine(content, func(_ int, line string) string {
+		if uncheckedExp.MatchString(line) {
+			items = append(items, line)
+
+			lead := len(line) - len(strings.TrimLeft(line, " \t"))
+			if indent < 0 || lead < indent {
+				indent = lead
+			}
+		}
+		return line
+	})
+
+	for i, item := range items {
+		items[i] = item[indent:]
+	}
+	return items
+}
+
+func markMigratedItems(content string) string {
+	return forEachLine(content, func(_ int, line string) string {
+		return uncheckedExp.ReplaceAllString(line, "${1}[>]${2}")
+	})
+}
@@ -0,0 +1,76 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_Rollover(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	yday := api.DayNoteName(time.Now().AddDate(0, 0, -1))
+	today := api.DayNoteName(time.Now())
+	content := strings.Join([]string{
+		"# Work log",
+		"- [x] done",
+		"- [ ] review PR",
+		"  - [ ] reply to comments",
+		"* [ ] call vendor",
+		"```",
+		"- [ ] not a task",
+		"```",
+	}, "\n")
+	if _, err := api.Put(Note{Name: yday, Content: content}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	items, err := api.UncheckedItems(yday)
+	if err != nil {
+		t.Fatalf("UncheckedItems() unexpected error: %v", err)
+	}
+	wantItems := []string{"- [ ] review PR", "  - [ ] reply to comments", "* [ ] call vendor"}
+	if !reflect.DeepEqual(items, wantItems) {
+		t.Errorf("UncheckedItems() got %q, want %q", items, wantItems)
+	}
+
+	n, count, err := api.Rollover(Note{Name: today, Content: "# Today\n"}, yday, true)
+	if err != nil {
+		t.Fatalf("Rollover() unexpected error: %v", err)
+	}
+	want := "# Today\n\n## Carried over from [[" + yday + "]]\n\n" + strings.Join(wantItems, "\n")
+	if count != 3 || n.Content != want {
+		t.Errorf("Rollover() got %d items and content %q, want 3 and %q", count, n.Content, want)
+	}
+
+	saved, err := api.Get(today)
+	if err != nil || saved.Content != want {
+		t.Errorf("Get() got %v (err=%v), want saved note", saved, err)
+	}
+
+	prev, err := api.Get(yday)
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	wantPrev := strings.Replace(content, "- [ ] review", "- [>] review", 1)
+	wantPrev = strings.Replace(wantPrev, "  - [ ] reply", "  - [>] reply", 1)
+	wantPrev = strings.Replace(wantPrev, "* [ ] call", "* [>] call", 1)
+	if prev.Content != wantPrev {
+		t.Errorf("Rollover() previous note got %q, want %q", prev.Content, wantPrev)
+	}
+
+	if _, _, err := api.Rollover(Note{Name: today}, yday, false); !errors.Is(err, ErrConflict) {
+		t.Errorf("Rollover() expected ErrConflict for existing note, got %v", err)
+	}
+	if _, _, err := api.Rollover(Note{Name: "other"}, "missing", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rollover() expected ErrNotFound, got %v", err)
+	}
+	if _, err := api.Get("other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rollover() must not create the note on failure, got %v", err)
+	}
+}
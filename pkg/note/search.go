@@ -0,0 +1,257 @@
+package note
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// tokenize splits the text into lower-cased terms. Any character that is not
+// a letter or a digit is treated as a separator.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexTerms returns all the terms in the text mapped to the positions they
+// appear at.
+func indexTerms(text string) map[string][]int {
+	terms := map[string][]int{}
+	for pos, term := range tokenize(text) {
+		terms[term] = append(terms[term], pos)
+	}
+	return terms
+}
+
+// parseText parses a free-text query into phrases. Double-quoted parts of the
+// text form a single phrase whose terms must appear consecutively, every other
+// word is a phrase by itself.
+func parseText(text string) [][]string {
+	var phrases [][]string
+	for i, part := range strings.Split(text, `"`) {
+		terms := tokenize(part)
+		if i%2 == 1 {
+			if len(terms) > 0 {
+				phrases = append(phrases, terms)
+			}
+			continue
+		}
+
+		for _, term := range terms {
+			phrases = append(phrases, []string{term})
+		}
+	}
+	return phrases
+}
+
+// textMatches returns names of all notes that contain every one of the given
+// phrases.
+func (api *API) textMatches(phrases [][]string) map[string]struct{} {
+	var candidates map[string]struct{}
+	for _, phrase := range phrases {
+		for _, term := range phrase {
+			names := api.terms[term]
+			if candidates == nil {
+				candidates = map[string]struct{}{}
+				for name := range names {
+					candidates[name] = struct{}{}
+				}
+				continue
+			}
+
+			for name := range candidates {
+				if _, found := names[name]; !found {
+					delete(candidates, name)
+				}
+			}
+		}
+	}
+
+	for name := range candidates {
+		node := api.idx[name]
+		for _, phrase := range phrases {
+			if !hasPhrase(node.Terms, phrase) {
+				delete(candidates, name)
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+func hasPhrase(terms map[string][]int, phrase []string) bool {
+	for _, start := range terms[phrase[0]] {
+		matched := true
+		for i, term := range phrase[1:] {
+			if !containsInt(terms[term], start+i+1) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(arr []int, v int) bool {
+	for _, item := range arr {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	bm25K1       = 1.2
+	bm25B        = 0.75
+	nameBoost    = 3.0
+	tagsBoost    = 1.5
+	snippetWords = 20
+)
+
+// Hit represents a single note matched by a search. Score and Snippet are
+// set only when sorted by relevance.
+type Hit struct {
+	Note    `yaml:",inline"`
+	Score   float64 `json:"score,omitempty" yaml:"score,omitempty"`
+	Snippet string  `json:"snippet,omitempty" yaml:"snippet,omitempty"`
+}
+
+// queryTerms returns the distinct terms in the query that can be used for
+// relevance ranking.
+func queryTerms(q Query) []string {
+	seen := map[string]struct{}{}
+
+	var terms []string
+	candidates := append(tokenize(q.Text), tokenize(q.NameLike)...)
+	if q.Expr != nil {
+		candidates = append(candidates, exprTerms(q.Expr)...)
+	}
+
+	for _, term := range candidates {
+		if _, found := seen[term]; !found {
+			seen[term] = struct{}{}
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// scoreBM25 scores the named notes against the terms using BM25F with name,
+// tags and content as fields. Matches in name and tags are boosted.
+func (api *API) scoreBM25(terms []string, names []string) map[string]float64 {
+	type fieldStats struct {
+		tf  map[string]float64
+		len float64
+	}
+
+	stats := make(map[string]fieldStats, len(api.idx))
+	var totalLen float64
+	for name, node := range api.idx {
+		fs := fieldStats{tf: map[string]float64{}, len: float64(node.Length)}
+		for term, pos := range node.Terms {
+			fs.tf[term] = float64(len(pos))
+		}
+
+		nameTerms := tokenize(name)
+		for _, term := range nameTerms {
+			fs.tf[term] += nameBoost
+		}
+
+		var tagTerms []string
+		for tag := range node.Tags {
+			tagTerms = append(tagTerms, tokenize(tag)...)
+		}
+		for _, term := range tagTerms {
+			fs.tf[term] += tagsBoost
+		}
+
+		fs.len += nameBoost*float64(len(nameTerms)) + tagsBoost*float64(len(tagTerms))
+		totalLen += fs.len
+		stats[name] = fs
+	}
+
+	n := float64(len(stats))
+	avgLen := totalLen / math.Max(n, 1)
+
+	idf := map[string]float64{}
+	for _, term := range terms {
+		var df float64
+		for _, fs := range stats {
+			if fs.tf[term] > 0 {
+				df++
+			}
+		}
+		idf[term] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+
+	scores := make(map[string]float64, len(names))
+	for _, name := range names {
+		fs := stats[name]
+
+		var score float64
+		for _, term := range terms {
+			tf := fs.tf[term]
+			if tf == 0 {
+				continue
+			}
+			norm := bm25K1 * (1 - bm25B + bm25B*fs.len/math.Max(avgLen, 1))
+			score += idf[term] * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		scores[name] = score
+	}
+	return scores
+}
+
+// makeSnippet returns a short excerpt of the content around the first word
+// that matches any of the terms. Matching words are highlighted using '**'.
+func makeSnippet(content string, terms []string) string {
+	termSet := arrToSet(terms)
+	isMatch := func(word string) bool {
+		for _, t := range tokenize(word) {
+			if _, found := termSet[t]; found {
+				return true
+			}
+		}
+		return false
+	}
+
+	words := strings.Fields(content)
+	start := 0
+	for i, word := range words {
+		if isMatch(word) {
+			start = i - snippetWords/3
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	var parts []string
+	for _, word := range words[start:end] {
+		if isMatch(word) {
+			word = "**" + word + "**"
+		}
+		parts = append(parts, word)
+	}
+
+	snippet := strings.Join(parts, " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(words) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,385 @@
+package note
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is the storage backend used by API. Keys are slash-separated paths
+// relative to the root of the store. API stores every note as '<name>.md'
+// and the index as 'notes_idx.json'.
+type Store interface {
+	// Get returns the data stored against the key. Returns ErrNotFound if
+	// the key does not exist.
+	Get(key string) ([]byte, error)
+
+	// Put creates or overwrites the data stored against the key.
+	Put(key string, d []byte) error
+
+	// Del removes the key. Removing a non-existent key is not an error.
+	Del(key string) error
+
+	// Stat returns the entry for the key. Returns ErrNotFound if the key
+	// does not exist.
+	Stat(key string) (*Entry, error)
+
+	// List returns all entries whose key has the given prefix. Entries under
+	// hidden directories (i.e., name starting with '.') are returned only if
+	// the prefix is inside the hidden directory.
+	List(prefix string) ([]Entry, error)
+
+	// String returns a human-readable location of the store.
+	String() string
+}
+
+// Locker is implemented by stores that can be shared by multiple processes.
+// API holds the lock while reading, modifying and writing back the index.
+type Locker interface {
+	// Lock acquires an exclusive lock on the store and returns a function to
+	// release it. Returns ErrBusy if the lock could not be acquired in time.
+	Lock() (unlock func(), err error)
+}
+
+// Entry represents a single item in the Store.
+type Entry struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// NewDirStore returns a Store backed by the given directory. If directory is
+// not found and init is true, it will be created automatically.
+func NewDirStore(dir string, init bool) (*DirStore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		} else if !init {
+			return nil, fmt.Errorf("profile directory non-existent")
+		}
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("profile path is not a directory")
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	ds := &DirStore{dir: dir, LockTimeout: DefaultLockTimeout}
+	return ds, ds.removeTempFiles()
+}
+
+// DirStore implements Store using files in a directory. Writes are atomic,
+// data is written to a temporary file first and then renamed.
+type DirStore struct {
+	dir string
+
+	// LockTimeout is the duration to wait for other processes to release
+	// the lock on the directory.
+	LockTimeout time.Duration
+}
+
+// Dir returns the root directory of the store.
+func (ds *DirStore) Dir() string { return ds.dir }
+
+func (ds *DirStore) String() string { return ds.dir }
+
+func (ds *DirStore) Get(key string) ([]byte, error) {
+	d, err := os.ReadFile(ds.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (ds *DirStore) Put(key string, d []byte) error {
+	path := ds.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, tempPattern)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(d); err != nil {
+		return err
+	} else if err := f.Sync(); err != nil {
+		return err
+	} else if err := f.Chmod(0644); err != nil {
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+func (ds *DirStore) Del(key string) error {
+	path := ds.path(key)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// remove parent directories left empty, stopping at the root.
+	for dir := filepath.Dir(path); dir != ds.dir && strings.HasPrefix(dir, ds.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// Lock acquires an advisory lock on the directory, waiting up to LockTimeout
+// for other processes to release it.
+func (ds *DirStore) Lock() (func(), error) {
+	f, err := os.OpenFile(filepath.Join(ds.dir, lockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(ds.LockTimeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		} else if locked {
+			return func() {
+				_ = unlockFile(f)
+				_ = f.Close()
+			}, nil
+		}
+
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%w: profile '%s' is in use by another process (waited %s)",
+				ErrBusy, ds.dir, ds.LockTimeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func (ds *DirStore) Stat(key string) (*Entry, error) {
+	info, err := os.Stat(ds.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+
+	return &Entry{
+		Key:     key,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+func (ds *DirStore) List(prefix string) ([]Entry, error) {
+	var res []Entry
+	walkErr := filepath.Walk(ds.dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(ds.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if path == ds.dir {
+				return nil
+			}
+
+			dir := key + "/"
+			if !strings.HasPrefix(dir, prefix) && !strings.HasPrefix(prefix, dir) {
+				return filepath.SkipDir
+			} else if strings.HasPrefix(info.Name(), ".") && !strings.HasPrefix(prefix, dir) {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if isTempFile(info.Name()) {
+			return nil
+		}
+
+		if isListed(key, prefix) {
+			res = append(res, Entry{
+				Key:     key,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+		return nil
+	})
+	return res, walkErr
+}
+
+func (ds *DirStore) path(key string) string {
+	return filepath.Join(ds.dir, filepath.FromSlash(key))
+}
+
+const (
+	tempPattern      = ".connote-*.tmp"
+	lockName         = "notes.lock"
+	lockPollInterval = 50 * time.Millisecond
+
+	// DefaultLockTimeout is the default LockTimeout of DirStore.
+	DefaultLockTimeout = 5 * time.Second
+)
+
+// removeTempFiles removes temporary files left behind by writes that were
+// interrupted. Files newer than LockTimeout may belong to a write still in
+// progress in another process and are left alone.
+func (ds *DirStore) removeTempFiles() error {
+	return filepath.Walk(ds.dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if !info.IsDir() && isTempFile(info.Name()) && time.Since(info.ModTime()) > ds.LockTimeout {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".connote-") && strings.HasSuffix(name, ".tmp")
+}
+
+// syncDir flushes the directory entry so that a rename survives a crash. Not
+// all platforms support this, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// NewMemStore returns an in-memory Store. Useful for tests and for embedding
+// where persistence is not required.
+func NewMemStore() *MemStore {
+	return &MemStore{items: map[string]memItem{}}
+}
+
+// MemStore implements Store using an in-memory map. MemStore is safe for
+// concurrent use.
+type MemStore struct {
+	mu    sync.RWMutex
+	items map[string]memItem
+}
+
+type memItem struct {
+	data    []byte
+	modTime time.Time
+}
+
+func (ms *MemStore) String() string { return "memory" }
+
+func (ms *MemStore) Get(key string) ([]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	item, found := ms.items[key]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return append([]byte(nil), item.data...), nil
+}
+
+func (ms *MemStore) Put(key string, d []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.items[key] = memItem{
+		data:    append([]byte(nil), d...),
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+func (ms *MemStore) Del(key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.items, key)
+	return nil
+}
+
+func (ms *MemStore) Stat(key string) (*Entry, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	item, found := ms.items[key]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	return &Entry{
+		Key:     key,
+		Size:    int64(len(item.data)),
+		ModTime: item.modTime,
+	}, nil
+}
+
+func (ms *MemStore) List(prefix string) ([]Entry, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var res []Entry
+	for key, item := range ms.items {
+		if isListed(key, prefix) {
+			res = append(res, Entry{
+				Key:     key,
+				Size:    int64(len(item.data)),
+				ModTime: item.modTime,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res, nil
+}
+
+// isListed reports whether the key must be listed for the prefix. Keys under
+// hidden directories are listed only if the prefix is inside the directory.
+func isListed(key, prefix string) bool {
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+
+	parts := strings.Split(key, "/")
+	dir := ""
+	for _, part := range parts[:len(parts)-1] {
+		dir += part + "/"
+		if strings.HasPrefix(part, ".") && !strings.HasPrefix(prefix, dir) {
+			return false
+		}
+	}
+	return true
+}
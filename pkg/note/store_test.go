@@ -0,0 +1,100 @@
+package note
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirStore(t *testing.T) {
+	dir := t.TempDir()
+
+	// leftover from an interrupted write and one from a write in progress
+	// in another process.
+	stale := filepath.Join(dir, ".connote-123.tmp")
+	inProgress := filepath.Join(dir, ".connote-456.tmp")
+	for _, path := range []string{stale, inProgress} {
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * DefaultLockTimeout)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := NewDirStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewDirStore() unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("NewDirStore() must remove stale temp file, got %v", err)
+	}
+	if _, err := os.Stat(inProgress); err != nil {
+		t.Errorf("NewDirStore() must not remove recent temp file, got %v", err)
+	}
+	_ = os.Remove(inProgress)
+
+	if err := ds.Put("a/b.md", []byte("hello")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := ds.Put("a/b.md", []byte("world")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	d, err := ds.Get("a/b.md")
+	if err != nil || string(d) != "world" {
+		t.Errorf("Get() = (%q, %v), want (\"world\", nil)", d, err)
+	}
+
+	entries, err := ds.List("")
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	} else if len(entries) != 1 || entries[0].Key != "a/b.md" || entries[0].Size != 5 {
+		t.Errorf("List() got %v, want only 'a/b.md'", entries)
+	}
+
+	if err := ds.Del("a/b.md"); err != nil {
+		t.Fatalf("Del() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("Del() must remove empty parent directory, got %v", err)
+	}
+
+	if _, err := NewDirStore(filepath.Join(dir, "missing"), false); err == nil {
+		t.Errorf("NewDirStore() expected error for missing directory")
+	}
+}
+
+func TestDirStore_Lock(t *testing.T) {
+	dir := t.TempDir()
+
+	ds1, err := NewDirStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewDirStore() unexpected error: %v", err)
+	}
+	ds2, err := NewDirStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewDirStore() unexpected error: %v", err)
+	}
+	ds2.LockTimeout = 100 * time.Millisecond
+
+	unlock, err := ds1.Lock()
+	if err != nil {
+		t.Fatalf("Lock() unexpected error: %v", err)
+	}
+
+	if _, err := ds2.Lock(); !errors.Is(err, ErrBusy) {
+		t.Errorf("Lock() expected ErrBusy, got %v", err)
+	}
+
+	unlock()
+	unlock2, err := ds2.Lock()
+	if err != nil {
+		t.Fatalf("Lock() unexpected error after release: %v", err)
+	}
+	unlock2()
+}
@@ -0,0 +1,367 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operators supported by TagFilter.
+const (
+	OpEq  = "="
+	OpNe  = "!="
+	OpGt  = ">"
+	OpGte = ">="
+	OpLt  = "<"
+	OpLte = "<="
+	OpIn  = "in"
+)
+
+// TagCount is a tag and the number of notes that have it.
+type TagCount struct {
+	Tag   string `json:"tag" yaml:"tag"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+// Tags returns all the tags in the profile with the number of notes having
+// them, sorted by count (descending) and then by tag.
+func (api *API) Tags() []TagCount {
+	counts := map[string]int{}
+	for _, node := range api.idx {
+		for tag := range node.Tags {
+			counts[tag]++
+		}
+	}
+
+	res := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		res = append(res, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Tag < res[j].Tag
+	})
+	return res
+}
+
+// TagNode is a tag in the hierarchy of tags. Count is the number of notes
+// having the tag or any of its descendants.
+type TagNode struct {
+	Name  string     `json:"name" yaml:"name"`
+	Tag   string     `json:"tag" yaml:"tag"`
+	Count int        `json:"count" yaml:"count"`
+	Tags  []*TagNode `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// TagTree returns the hierarchy of all tags in the profile. Count of the root
+// is the number of notes having any tag.
+func (api *API) TagTree() *TagNode {
+	root := &TagNode{Name: api.profile}
+	for _, node := range api.idx {
+		if len(node.Tags) > 0 {
+			root.Count++
+		}
+
+		// a note with 'team/a' and 'team/b' counts once for 'team'.
+		counted := map[*TagNode]struct{}{}
+		for tag := range node.Tags {
+			tn := root
+			for _, part := range strings.Split(tag, "/") {
+				tn = tn.child(part)
+				if _, found := counted[tn]; !found {
+					counted[tn] = struct{}{}
+					tn.Count++
+				}
+			}
+		}
+	}
+
+	root.sort()
+	return root
+}
+
+func (tn *TagNode) child(name string) *TagNode {
+	for _, c := range tn.Tags {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	tag := name
+	if tn.Tag != "" {
+		tag = tn.Tag + "/" + name
+	}
+
+	c := &TagNode{Name: name, Tag: tag}
+	tn.Tags = append(tn.Tags, c)
+	return c
+}
+
+func (tn *TagNode) sort() {
+	sort.Slice(tn.Tags, func(i, j int) bool {
+		return tn.Tags[i].Name < tn.Tags[j].Name
+	})
+	for _, c := range tn.Tags {
+		c.sort()
+	}
+}
+
+// RenameTag renames the tag in all the notes having it and returns the number
+// of notes updated. Descendant tags (e.g., 'team/payments' of 'team') are
+// renamed as well, and so are key:value tags (e.g., 'env:prod') if the tag is
+// a plain key (e.g., 'env').
+func (api *API) RenameTag(from, to string) (int, error) {
+	return api.MergeTags([]string{from}, to)
+}
+
+// MergeTags replaces all the given tags with the tag 'into' in all the notes
+// having any of them and returns the number of notes updated. Tags are
+// matched as in RenameTag.
+func (api *API) MergeTags(tags []string, into string) (int, error) {
+	into = strings.TrimSpace(into)
+	if into == "" {
+		return 0, fmt.Errorf("target tag must not be empty")
+	}
+
+	return api.updateTags("retag", func(tag string) (string, bool) {
+		for _, old := range tags {
+			if rest, ok := matchTag(tag, strings.TrimSpace(old)); ok {
+				if strings.HasPrefix(rest, ":") && strings.Contains(into, ":") {
+					return into, true
+				}
+				return into + rest, true
+			}
+		}
+		return tag, true
+	})
+}
+
+// DeleteTag removes the tag from all the notes having it and returns the
+// number of notes updated. Tags are matched as in RenameTag.
+func (api *API) DeleteTag(tag string) (int, error) {
+	tag = strings.TrimSpace(tag)
+	return api.updateTags("untag", func(t string) (string, bool) {
+		_, matched := matchTag(t, tag)
+		return t, !matched
+	})
+}
+
+// updateTags rewrites the tags of all notes using fn, which returns the new
+// tag and whether to keep it. Affected notes are updated in a single journaled
+// operation.
+func (api *API) updateTags(op string, fn func(tag string) (string, bool)) (int, error) {
+	affected := func(tags map[string]struct{}) bool {
+		for tag := range tags {
+			if updated, keep := fn(tag); !keep || updated != tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	var names []string
+	for name, node := range api.idx {
+		if affected(node.Tags) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	err := api.apply(op, names, func() error {
+		for _, name := range names {
+			// index may have changed since names were collected.
+			node, found := api.idx[name]
+			if !found || !affected(node.Tags) {
+				continue
+			}
+
+			n, err := api.Get(name)
+			if err != nil {
+				return err
+			}
+
+			var tags []string
+			for _, tag := range n.Tags {
+				if updated, keep := fn(tag); keep {
+					tags = append(tags, updated)
+				}
+			}
+			n.Tags = tags
+			n.UpdatedAt = time.Now()
+			if err := n.Validate(); err != nil {
+				return err
+			} else if err := api.saveRevision(*n); err != nil {
+				return err
+			} else if err := api.store.Put(noteKey(name), n.ToMarkdown()); err != nil {
+				return err
+			} else if err := api.putNode(name, newIndexNode(*n)); err != nil {
+				return err
+			}
+			count++
+		}
+		return api.syncIdx()
+	})
+	return count, err
+}
+
+// matchTag returns true if the tag is same as the pattern or its descendant,
+// or if the pattern is a plain key and the tag has a value for it. rest is
+// the '/child' or ':value' suffix of the matched tag, if any.
+func matchTag(tag, pattern string) (rest string, ok bool) {
+	if tag == pattern {
+		return "", true
+	} else if strings.HasPrefix(tag, pattern+"/") {
+		return tag[len(pattern):], true
+	} else if !strings.Contains(pattern, ":") && strings.HasPrefix(tag, pattern+":") {
+		return tag[len(pattern):], true
+	}
+	return "", false
+}
+
+// hasTag returns true if the note has the tag or any of its descendants.
+// Tags are hierarchical with '/' as the separator (e.g., 'team/payments' is
+// a descendant of 'team').
+func (node indexNode) hasTag(tag string) bool {
+	if _, found := node.Tags[tag]; found {
+		return true
+	}
+
+	for t := range node.Tags {
+		if strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// TagFilter matches notes by values of a key:value tag. For example, filter
+// 'priority>=2' matches a note with tag 'priority:3'. A note can have more
+// than one value for a key (e.g., 'env:prod' and 'env:stg') and the filter
+// matches if any of the values satisfy it. Plain tags (e.g., 'urgent') are
+// keys with an empty value.
+type TagFilter struct {
+	Key    string   `json:"key"`
+	Op     string   `json:"op"`
+	Values []string `json:"values"`
+}
+
+// ParseTagFilter parses filters of the form 'key=value', 'key=*' (note has
+// the key), 'key!=value', 'key>=value' (also >, <, <=) and 'key in (a,b)'.
+// Values are compared as numbers if both sides are numeric.
+func ParseTagFilter(s string) (TagFilter, error) {
+	s = strings.TrimSpace(s)
+
+	if idx := strings.Index(strings.ToLower(s), " in "); idx > 0 {
+		key := strings.TrimSpace(s[:idx])
+		list := strings.TrimSpace(s[idx+len(" in "):])
+		if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+			return TagFilter{}, fmt.Errorf("invalid tag filter '%s': values must be in parentheses", s)
+		}
+
+		var values []string
+		for _, v := range strings.Split(list[1:len(list)-1], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return TagFilter{}, fmt.Errorf("invalid tag filter '%s': no values", s)
+		}
+		return TagFilter{Key: key, Op: OpIn, Values: values}, nil
+	}
+
+	for _, op := range []string{OpGte, OpLte, OpNe, OpEq, OpGt, OpLt} {
+		idx := strings.Index(s, op)
+		if idx < 0 {
+			continue
+		}
+
+		key, value := strings.TrimSpace(s[:idx]), strings.TrimSpace(s[idx+len(op):])
+		if key == "" || value == "" {
+			return TagFilter{}, fmt.Errorf("invalid tag filter '%s': key and value are required", s)
+		}
+		return TagFilter{Key: key, Op: op, Values: []string{value}}, nil
+	}
+
+	return TagFilter{}, fmt.Errorf("invalid tag filter '%s': no operator", s)
+}
+
+func (f TagFilter) String() string {
+	if f.Op == OpIn {
+		return fmt.Sprintf("%s in (%s)", f.Key, strings.Join(f.Values, ","))
+	}
+	return f.Key + f.Op + strings.Join(f.Values, ",")
+}
+
+func (f TagFilter) match(node indexNode) bool {
+	values, found := node.Keys[f.Key]
+
+	switch f.Op {
+	case OpEq, OpIn:
+		return found && f.anyEqual(values)
+	case OpNe:
+		return !found || !f.anyEqual(values)
+	}
+
+	for _, v := range values {
+		c := compareValues(v, f.Values[0])
+		switch {
+		case f.Op == OpGt && c > 0,
+			f.Op == OpGte && c >= 0,
+			f.Op == OpLt && c < 0,
+			f.Op == OpLte && c <= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func (f TagFilter) anyEqual(values []string) bool {
+	for _, want := range f.Values {
+		if want == "*" {
+			return true
+		}
+		for _, v := range values {
+			if compareValues(v, want) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareValues compares tag values as numbers if both are numeric and as
+// strings otherwise.
+func compareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// tagKeys returns the values of the tags grouped by their keys.
+func tagKeys(tags []string) map[string][]string {
+	keys := map[string][]string{}
+	for _, tag := range tags {
+		k, v := splitTag(tag)
+		keys[k] = append(keys[k], v)
+	}
+	return keys
+}
@@ -0,0 +1,308 @@
+package note
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    TagFilter
+		wantErr bool
+	}{
+		{s: "project=*", want: TagFilter{Key: "project", Op: OpEq, Values: []string{"*"}}},
+		{s: "priority >= 2", want: TagFilter{Key: "priority", Op: OpGte, Values: []string{"2"}}},
+		{s: "env!=prod", want: TagFilter{Key: "env", Op: OpNe, Values: []string{"prod"}}},
+		{s: "env in (prod, stg)", want: TagFilter{Key: "env", Op: OpIn, Values: []string{"prod", "stg"}}},
+		{s: "env IN (prod)", want: TagFilter{Key: "env", Op: OpIn, Values: []string{"prod"}}},
+		{s: "env in prod", wantErr: true},
+		{s: "env in ()", wantErr: true},
+		{s: "=prod", wantErr: true},
+		{s: "priority>", wantErr: true},
+		{s: "project", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseTagFilter(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTagFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTagFilter() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI_Search_TagFilters(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "na", Tags: []string{"env:prod", "env:stg", "priority:10", "project:kafka"}},
+		{Name: "nb", Tags: []string{"env:dev", "priority:2"}},
+		{Name: "nc", Tags: []string{"env:stg", "priority:1", "project"}},
+		{Name: "nd"},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{filter: "env=prod", want: "na"},
+		{filter: "env=stg", want: "na,nc"},
+		{filter: "env!=stg", want: "nb,nd"},
+		{filter: "project=*", want: "na,nc"},
+		{filter: "priority>=2", want: "na,nb"},
+		{filter: "priority<2", want: "nc"},
+		{filter: "env in (prod,dev)", want: "na,nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseTagFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseTagFilter() unexpected error: %v", err)
+			}
+
+			res, err := api.Search(Query{TagFilters: []TagFilter{f}, Sort: SortName}, false)
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+			if got := hitNames(res); got != tt.want {
+				t.Errorf("Search() got %s, want %s", got, tt.want)
+			}
+
+			// same filter must work in the query language.
+			q, err := ParseQuery(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseQuery() unexpected error: %v", err)
+			}
+			q.Sort = SortName
+
+			res, err = api.Search(q, false)
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+			if got := hitNames(res); got != tt.want {
+				t.Errorf("Search() with query got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func hitNames(hits []Hit) string {
+	var names []string
+	for _, h := range hits {
+		names = append(names, h.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestAPI_TagOps(t *testing.T) {
+	store := NewMemStore()
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "na", Tags: []string{"kafak", "env:prod", "oncall"}},
+		{Name: "nb", Tags: []string{"kafka", "env:stg"}},
+		{Name: "nc", Tags: []string{"redis", "oncall"}},
+		{Name: "nd", Tags: []string{"draft"}},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	tags := func() string {
+		var res []string
+		for _, tc := range api.Tags() {
+			res = append(res, fmt.Sprintf("%s=%d", tc.Tag, tc.Count))
+		}
+		return strings.Join(res, ",")
+	}
+
+	if got, want := tags(), "oncall=2,draft=1,env:prod=1,env:stg=1,kafak=1,kafka=1,redis=1"; got != want {
+		t.Errorf("Tags() got %s, want %s", got, want)
+	}
+
+	steps := []struct {
+		name  string
+		op    func() (int, error)
+		count int
+		want  string
+	}{
+		{
+			name:  "Rename",
+			op:    func() (int, error) { return api.RenameTag("kafak", "kafka") },
+			count: 1,
+			want:  "kafka=2,oncall=2,draft=1,env:prod=1,env:stg=1,redis=1",
+		},
+		{
+			name:  "RenameKey",
+			op:    func() (int, error) { return api.RenameTag("env", "environment") },
+			count: 2,
+			want:  "kafka=2,oncall=2,draft=1,environment:prod=1,environment:stg=1,redis=1",
+		},
+		{
+			name:  "Merge",
+			op:    func() (int, error) { return api.MergeTags([]string{"kafka", "redis"}, "infra") },
+			count: 3,
+			want:  "infra=3,oncall=2,draft=1,environment:prod=1,environment:stg=1",
+		},
+		{
+			name:  "Delete",
+			op:    func() (int, error) { return api.DeleteTag("environment") },
+			count: 2,
+			want:  "infra=3,oncall=2,draft=1",
+		},
+		{
+			name:  "DeleteMissing",
+			op:    func() (int, error) { return api.DeleteTag("missing") },
+			count: 0,
+			want:  "infra=3,oncall=2,draft=1",
+		},
+	}
+	for _, st := range steps {
+		count, err := st.op()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", st.name, err)
+		}
+		if count != st.count {
+			t.Errorf("%s: updated %d notes, want %d", st.name, count, st.count)
+		}
+		if got := tags(); got != st.want {
+			t.Errorf("%s: Tags() got %s, want %s", st.name, got, st.want)
+		}
+	}
+
+	// front-matter must be rewritten and previous version kept in history.
+	reopened, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	n, err := reopened.Get("na")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(n.Tags, []string{"infra", "oncall"}) {
+		t.Errorf("Get() tags = %v, want [infra oncall]", n.Tags)
+	}
+	if revs, err := reopened.History("na"); err != nil || len(revs) != 4 {
+		t.Errorf("History() got %d revisions (err=%v), want 4", len(revs), err)
+	}
+}
+
+func TestAPI_TagOps_HandWritten(t *testing.T) {
+	store := NewMemStore()
+	_ = store.Put("notes/kafka.md", []byte("---\ntags: [oncall, infra]\n---\n\n# Kafka"))
+
+	api, err := Open("test", store, nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	before, err := api.Get("notes/kafka")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	if count, err := api.RenameTag("oncall", "on-call"); err != nil || count != 1 {
+		t.Fatalf("RenameTag() got (%d, %v), want (1, nil)", count, err)
+	}
+
+	n, err := Parse(mustGet(t, store, "notes/kafka.md"))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if n.Name != "notes/kafka" || !reflect.DeepEqual(n.Tags, []string{"on-call", "infra"}) {
+		t.Errorf("RenameTag() saved name %q and tags %v, want 'notes/kafka' and [on-call infra]", n.Name, n.Tags)
+	}
+	if !n.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("RenameTag() changed creation time from %v to %v", before.CreatedAt, n.CreatedAt)
+	}
+}
+
+func mustGet(t *testing.T, store Store, key string) []byte {
+	t.Helper()
+	d, err := store.Get(key)
+	if err != nil {
+		t.Fatalf("Get(%q) unexpected error: %v", key, err)
+	}
+	return d
+}
+
+func TestAPI_HierarchicalTags(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "na", Tags: []string{"team/payments/oncall", "team/payments/"}},
+		{Name: "nb", Tags: []string{"team/payments/review"}},
+		{Name: "nc", Tags: []string{"team/search"}},
+		{Name: "nd", Tags: []string{"teams"}},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	search := func(q Query) string {
+		q.Sort = SortName
+		res, err := api.Search(q, false)
+		if err != nil {
+			t.Fatalf("Search() unexpected error: %v", err)
+		}
+		return hitNames(res)
+	}
+
+	if got := search(Query{IncludeTags: []string{"team/payments"}}); got != "na,nb" {
+		t.Errorf("Search() include got %s, want na,nb", got)
+	}
+	if got := search(Query{IncludeTags: []string{"team"}, ExcludeTags: []string{"team/payments/review"}}); got != "na,nc" {
+		t.Errorf("Search() include/exclude got %s, want na,nc", got)
+	}
+	if q, _ := ParseQuery("tag:team -tag:team/search"); search(q) != "na,nb" {
+		t.Errorf("Search() with query got %s, want na,nb", search(q))
+	}
+
+	tree := api.TagTree()
+	var render func(tn *TagNode) string
+	render = func(tn *TagNode) string {
+		var children []string
+		for _, c := range tn.Tags {
+			children = append(children, render(c))
+		}
+		label := fmt.Sprintf("%s=%d", tn.Name, tn.Count)
+		if len(children) > 0 {
+			label += "(" + strings.Join(children, ",") + ")"
+		}
+		return label
+	}
+	want := "test=4(team=3(payments=2(oncall=1,review=1),search=1),teams=1)"
+	if got := render(tree); got != want {
+		t.Errorf("TagTree() got %s, want %s", got, want)
+	}
+
+	if count, err := api.RenameTag("team/payments", "payments"); err != nil || count != 2 {
+		t.Fatalf("RenameTag() updated %d (err=%v), want 2", count, err)
+	}
+	if n, _ := api.Get("na"); !reflect.DeepEqual(n.Tags, []string{"payments/oncall", "payments"}) {
+		t.Errorf("RenameTag() tags = %v, want [payments/oncall payments]", n.Tags)
+	}
+}
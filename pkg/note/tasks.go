@@ -0,0 +1,226 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Task states for TaskQuery.
+const (
+	TaskOpen = "open"
+	TaskDone = "done"
+)
+
+var (
+	taskExp   = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])(\] (.*))$`)
+	dueExp    = regexp.MustCompile(`(?:^|\s)due:(\d{4}-\d{2}-\d{2})\b`)
+	personExp = regexp.MustCompile(`(?:^|\s)@([\w.-]*\w)`)
+	hashExp   = regexp.MustCompile(`(?:^|\s)#([\w/-]*\w)`)
+)
+
+// Task is a checklist item ('- [ ] ...' or '- [x] ...') in a note. Line is
+// the 1-based line number in the note content. Due date ('due:2006-01-02'),
+// people ('@name') and tags ('#tag') are parsed from the text.
+type Task struct {
+	Note   string     `json:"note" yaml:"note"`
+	Line   int        `json:"line" yaml:"line"`
+	Text   string     `json:"text" yaml:"text"`
+	Done   bool       `json:"done" yaml:"done"`
+	Due    *time.Time `json:"due,omitempty" yaml:"due,omitempty"`
+	People []string   `json:"people,omitempty" yaml:"people,omitempty"`
+	Tags   []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// TaskQuery represents filtering options for tasks. Notes selects the notes
+// to find tasks in, sort and pagination of it are ignored. State is one of
+// TaskOpen, TaskDone or empty for all. Tag matches task tags and their
+// descendants. DueRange is a pair of unix timestamps (inclusive), tasks
+// without due date are excluded if it is set. Zero end of the range means
+// no limit.
+type TaskQuery struct {
+	Notes    Query    `json:"notes"`
+	State    string   `json:"state"`
+	Tag      string   `json:"tag"`
+	Person   string   `json:"person"`
+	DueRange [2]int64 `json:"due_range"`
+}
+
+type indexTask struct {
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Done   bool     `json:"done,omitempty"`
+	Due    int64    `json:"due,omitempty"`
+	People []string `json:"people,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Tasks returns the tasks matching the query, sorted by due date (tasks
+// without one last), note and line.
+func (api *API) Tasks(q TaskQuery) ([]Task, error) {
+	switch q.State {
+	case "", TaskOpen, TaskDone:
+	default:
+		return nil, fmt.Errorf("invalid task state '%s'", q.State)
+	}
+
+	nq := q.Notes
+	nq.Sort, nq.Offset, nq.Limit = SortName, 0, 0
+	hits, err := api.Search(nq, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Task{}
+	for _, h := range hits {
+		for _, it := range api.idx[h.Name].Tasks {
+			if q.isMatch(it) {
+				res = append(res, it.toTask(h.Name))
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if (a.Due == nil) != (b.Due == nil) {
+			return a.Due != nil
+		} else if a.Due != nil && !a.Due.Equal(*b.Due) {
+			return a.Due.Before(*b.Due)
+		} else if a.Note != b.Note {
+			return a.Note < b.Note
+		}
+		return a.Line < b.Line
+	})
+	return res, nil
+}
+
+// ToggleTask toggles the checkbox of the task at the given line of the note
+// content and returns the updated task.
+func (api *API) ToggleTask(name string, line int) (*Task, error) {
+	name = strings.TrimSpace(name)
+
+	var task Task
+	err := api.apply("task", []string{name}, func() error {
+		n, err := api.Get(name)
+		if err != nil {
+			return err
+		}
+
+		// lines in code blocks are not tasks, same as in parseTasks.
+		found := false
+		content := forEachLine(n.Content, func(i int, l string) string {
+			m := taskExp.FindStringSubmatch(l)
+			if i != line-1 || m == nil {
+				return l
+			}
+
+			found = true
+			state := "x"
+			if m[2] != " " {
+				state = " "
+			}
+			return m[1] + state + m[3]
+		})
+		if !found {
+			return fmt.Errorf("%w: task at line %d of '%s'", ErrNotFound, line, name)
+		}
+
+		n.Name = name
+		n.Content = content
+		n.UpdatedAt = time.Now()
+		if err := api.put(n, false, !n.CreatedAt.IsZero()); err != nil {
+			return err
+		}
+
+		for _, it := range api.idx[name].Tasks {
+			if it.Line == line {
+				task = it.toTask(name)
+			}
+		}
+		return api.syncIdx()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (q TaskQuery) isMatch(it indexTask) bool {
+	if (q.State == TaskOpen && it.Done) || (q.State == TaskDone && !it.Done) {
+		return false
+	}
+
+	if q.Tag != "" {
+		if !(indexNode{Tags: arrToSet(it.Tags)}).hasTag(q.Tag) {
+			return false
+		}
+	}
+
+	if q.Person != "" && !containsString(it.People, strings.TrimPrefix(q.Person, "@")) {
+		return false
+	}
+
+	if q.DueRange != [2]int64{} {
+		after, before := q.DueRange[0], q.DueRange[1]
+		if it.Due == 0 || it.Due < after || (before != 0 && it.Due > before) {
+			return false
+		}
+	}
+	return true
+}
+
+func (it indexTask) toTask(note string) Task {
+	t := Task{
+		Note:   note,
+		Line:   it.Line,
+		Text:   it.Text,
+		Done:   it.Done,
+		People: it.People,
+		Tags:   it.Tags,
+	}
+	if it.Due != 0 {
+		due := time.Unix(it.Due, 0)
+		t.Due = &due
+	}
+	return t
+}
+
+// parseTasks returns the checklist items in the content. Items in code
+// blocks are ignored.
+func parseTasks(content string) []indexTask {
+	var tasks []indexTask
+	forEachLine(content, func(i int, line string) string {
+		m := taskExp.FindStringSubmatch(line)
+		if m == nil {
+			return line
+		}
+
+		text := strings.TrimSpace(m[4])
+		it := indexTask{Line: i + 1, Text: text, Done: m[2] != " "}
+		if dm := dueExp.FindStringSubmatch(text); dm != nil {
+			if due, err := time.ParseInLocation("2006-01-02", dm[1], time.Local); err == nil {
+				it.Due = due.Unix()
+			}
+		}
+		for _, pm := range personExp.FindAllStringSubmatch(text, -1) {
+			it.People = append(it.People, pm[1])
+		}
+		for _, hm := range hashExp.FindAllStringSubmatch(text, -1) {
+			it.Tags = append(it.Tags, hm[1])
+		}
+		tasks = append(tasks, it)
+		return line
+	})
+	return tasks
+}
+
+func containsString(arr []string, s string) bool {
+	for _, item := range arr {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTasks(t *testing.T) {
+	content := strings.Join([]string{
+		"# Tasks",
+		"- [ ] email @alice about #work/budget due:2021-03-04",
+		"  * [x] sent draft",
+		"- [>] migrated",
+		"```",
+		"- [ ] not a task",
+		"```",
+		"+ [X] ship it #release",
+	}, "\n")
+
+	due, _ := time.ParseInLocation("2006-01-02", "2021-03-04", time.Local)
+	want := []indexTask{
+		{Line: 2, Text: "email @alice about #work/budget due:2021-03-04", Due: due.Unix(), People: []string{"alice"}, Tags: []string{"work/budget"}},
+		{Line: 3, Text: "sent draft", Done: true},
+		{Line: 8, Text: "ship it #release", Done: true, Tags: []string{"release"}},
+	}
+	if got := parseTasks(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTasks() got %+v, want %+v", got, want)
+	}
+}
+
+func TestAPI_Tasks(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	notes := []Note{
+		{Name: "alpha", Tags: []string{"work"}, Content: "- [ ] plan #work due:2021-03-05\n- [x] review @bob"},
+		{Name: "beta", Content: "- [ ] buy milk\n- [ ] pay rent due:2021-03-01 #home"},
+	}
+	for _, n := range notes {
+		if _, err := api.Put(n, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	day := func(s string) int64 {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return d.Unix()
+	}
+
+	tests := []struct {
+		name string
+		q    TaskQuery
+		want []string
+	}{
+		{"All", TaskQuery{}, []string{"beta:2", "alpha:1", "alpha:2", "beta:1"}},
+		{"Open", TaskQuery{State: TaskOpen}, []string{"beta:2", "alpha:1", "beta:1"}},
+		{"Done", TaskQuery{State: TaskDone}, []string{"alpha:2"}},
+		{"Tag", TaskQuery{Tag: "home"}, []string{"beta:2"}},
+		{"Person", TaskQuery{Person: "@bob"}, []string{"alpha:2"}},
+		{"Notes", TaskQuery{Notes: Query{IncludeTags: []string{"work"}}}, []string{"alpha:1", "alpha:2"}},
+		{"DueBefore", TaskQuery{DueRange: [2]int64{0, day("2021-03-02")}}, []string{"beta:2"}},
+		{"DueAfter", TaskQuery{DueRange: [2]int64{day("2021-03-02"), 0}}, []string{"alpha:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := api.Tasks(tt.q)
+			if err != nil {
+				t.Fatalf("Tasks() unexpected error: %v", err)
+			}
+			got := []string{}
+			for _, task := range tasks {
+				got = append(got, task.Note+":"+strconv.Itoa(task.Line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tasks() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := api.Tasks(TaskQuery{State: "maybe"}); err == nil {
+		t.Errorf("Tasks() expected error for invalid state")
+	}
+}
+
+func TestAPI_ToggleTask(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if _, err := api.Put(Note{Name: "alpha", Content: "# Todo\n- [ ] plan\n- [x] review"}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	task, err := api.ToggleTask("alpha", 2)
+	if err != nil {
+		t.Fatalf("ToggleTask() unexpected error: %v", err)
+	} else if !task.Done || task.Text != "plan" {
+		t.Errorf("ToggleTask() got %+v, want done task 'plan'", task)
+	}
+
+	if task, err = api.ToggleTask("alpha", 3); err != nil || task.Done {
+		t.Errorf("ToggleTask() got %+v (err=%v), want open task", task, err)
+	}
+
+	n, err := api.Get("alpha")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	} else if want := "# Todo\n- [x] plan\n- [ ] review"; n.Content != want {
+		t.Errorf("ToggleTask() content got %q, want %q", n.Content, want)
+	}
+
+	if revs, err := api.History("alpha"); err != nil || len(revs) != 2 {
+		t.Errorf("History() got %d revisions (err=%v), want 2", len(revs), err)
+	}
+
+	if _, err := api.ToggleTask("alpha", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ToggleTask() expected ErrNotFound for non-task line, got %v", err)
+	}
+	if _, err := api.ToggleTask("missing", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ToggleTask() expected ErrNotFound for missing note, got %v", err)
+	}
+
+	fenced := "# Sample\n```\n- [x] not a task\n```"
+	if _, err := api.Put(Note{Name: "beta", Content: fenced}, true); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := api.ToggleTask("beta", 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ToggleTask() expected ErrNotFound for line in code block, got %v", err)
+	}
+	if n, err = api.Get("beta"); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	} else if n.Content != fenced {
+		t.Errorf("ToggleTask() changed code block to %q", n.Content)
+	}
+}
@@ -0,0 +1,120 @@
+package note
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const templateDir = ".templates/"
+
+// TemplateData is available to templates when creating a note. Date is the
+// start of the period for periodic notes (e.g., the day of a day note) and
+// the creation time otherwise. PrevDay is the name of the latest day note
+// before Date, if any.
+type TemplateData struct {
+	Name    string
+	Title   string
+	Profile string
+	Date    time.Time
+	PrevDay string
+}
+
+// templateFuncs returns the functions available to templates. Names of
+// periodic notes follow the formats of the API.
+func (api *API) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"now":        time.Now,
+		"dayNote":    api.DayNoteName,
+		"periodNote": api.PeriodNoteName,
+		"addDays":    func(t time.Time, days int) time.Time { return t.AddDate(0, 0, days) },
+		"date":       func(layout string, t time.Time) string { return t.Format(layout) },
+	}
+}
+
+// Templates returns the names of templates in the profile, sorted.
+func (api *API) Templates() ([]string, error) {
+	entries, err := api.store.List(templateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.Key, templateDir)
+		if strings.HasSuffix(name, ".md") {
+			names = append(names, strings.TrimSuffix(name, ".md"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Template returns the text of the named template. Returns ErrNotFound if
+// the template does not exist.
+func (api *API) Template(name string) (string, error) {
+	d, err := api.store.Get(templateKey(name))
+	if err != nil {
+		return "", fmt.Errorf("template '%s': %w", name, err)
+	}
+	return string(d), nil
+}
+
+// PutTemplate saves the template text with the given name. Template is a
+// markdown note (optionally with front-matter) with text/template actions
+// that are executed with TemplateData.
+func (api *API) PutTemplate(name, text string) error {
+	if !nameExp.MatchString(strings.TrimSpace(name)) {
+		return fmt.Errorf("invalid template name: '%s'", name)
+	} else if _, err := template.New(name).Funcs(api.templateFuncs()).Parse(text); err != nil {
+		return err
+	}
+	return api.store.Put(templateKey(name), []byte(text))
+}
+
+// FromTemplate creates a new note with given name by executing the named
+// template. The note is not saved. Date is used as TemplateData.Date unless
+// the note is a periodic note.
+func (api *API) FromTemplate(name, tmplName string, date time.Time) (*Note, error) {
+	name = strings.TrimSpace(name)
+
+	text, err := api.Template(tmplName)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(tmplName).Funcs(api.templateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %v", tmplName, err)
+	}
+
+	if _, start, ok := api.ParsePeriodNoteName(name); ok {
+		date = start
+	}
+	data := TemplateData{
+		Name:    name,
+		Title:   name[strings.LastIndex(name, "/")+1:],
+		Profile: api.profile,
+		Date:    date,
+	}
+	data.PrevDay, _ = api.PrevDayNote(date)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("template '%s': %v", tmplName, err)
+	}
+
+	n, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %v", tmplName, err)
+	}
+	n.Name = name
+	return n, nil
+}
+
+func templateKey(name string) string {
+	return templateDir + strings.TrimSpace(name) + ".md"
+}
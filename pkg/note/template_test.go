@@ -0,0 +1,77 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPI_FromTemplate(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	standup := "---\ntags: [standup]\n---\n# {{ .Title }} ({{ .Profile }})\n\n" +
+		"Date: {{ date \"2006-01-02\" .Date }}\n" +
+		"{{ with .PrevDay }}Previous: [[{{ . }}]]{{ else }}First day{{ end }}\n" +
+		"Tomorrow: [[{{ dayNote (addDays .Date 1) }}]]"
+	if err := api.PutTemplate("standup", standup); err != nil {
+		t.Fatalf("PutTemplate() unexpected error: %v", err)
+	}
+	if err := api.PutTemplate("broken", "{{ .Name "); err == nil {
+		t.Errorf("PutTemplate() expected error for invalid template")
+	}
+
+	if names, err := api.Templates(); err != nil || !reflect.DeepEqual(names, []string{"standup"}) {
+		t.Errorf("Templates() got %v (err=%v), want [standup]", names, err)
+	}
+
+	day := time.Date(2022, 3, 10, 0, 0, 0, 0, time.Local)
+	for _, d := range []time.Time{day.AddDate(0, 0, -5), day.AddDate(0, 0, -2), day.AddDate(0, 0, 1)} {
+		if _, err := api.Put(Note{Name: api.DayNoteName(d)}, true); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+	}
+
+	n, err := api.FromTemplate(api.DayNoteName(day), "standup", time.Now())
+	if err != nil {
+		t.Fatalf("FromTemplate() unexpected error: %v", err)
+	}
+	want := "# day:10-Mar-2022 (test)\n\nDate: 2022-03-10\nPrevious: [[day:8-Mar-2022]]\nTomorrow: [[day:11-Mar-2022]]"
+	if n.Name != "day:10-Mar-2022" || n.Content != want || !reflect.DeepEqual(n.Tags, []string{"standup"}) {
+		t.Errorf("FromTemplate() got %+v, want content %q", n, want)
+	}
+
+	n, err = api.FromTemplate("team/retro", "standup", day.AddDate(0, 0, -5))
+	if err != nil {
+		t.Fatalf("FromTemplate() unexpected error: %v", err)
+	}
+	want = "# retro (test)\n\nDate: 2022-03-05\nFirst day\nTomorrow: [[day:6-Mar-2022]]"
+	if n.Content != want {
+		t.Errorf("FromTemplate() got %q, want %q", n.Content, want)
+	}
+
+	if _, err := api.FromTemplate("foo", "missing", day); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FromTemplate() expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAPI_ParseDayNoteName(t *testing.T) {
+	api, err := Open("test", NewMemStore(), nopLog)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	day := time.Date(2022, 10, 6, 0, 0, 0, 0, time.Local)
+	if got, ok := api.ParseDayNoteName(api.DayNoteName(day)); !ok || !got.Equal(day) {
+		t.Errorf("ParseDayNoteName() got %v, %v, want %v", got, ok, day)
+	}
+
+	for _, name := range []string{"kafka", "day:32-Oct-2022", "day:"} {
+		if _, ok := api.ParseDayNoteName(name); ok {
+			t.Errorf("ParseDayNoteName(%s) expected false", name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const trashDir = ".trash/"
+
+// Trashed represents a deleted note in the trash.
+type Trashed struct {
+	Name      string    `json:"name" yaml:"name"`
+	DeletedAt time.Time `json:"deleted_at" yaml:"deleted_at"`
+
+	key string
+}
+
+// Trash returns all notes in the trash, most recently deleted first.
+func (api *API) Trash() ([]Trashed, error) {
+	entries, err := api.store.List(trashDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Trashed{}
+	for _, entry := range entries {
+		t, ok := parseTrashKey(entry.Key)
+		if !ok {
+			api.log("warn", "ignoring unknown trash entry '%s'", entry.Key)
+			continue
+		}
+		res = append(res, t)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].DeletedAt.After(res[j].DeletedAt)
+	})
+	return res, nil
+}
+
+// Restore moves the most recently deleted note with given name out of the
+// trash. Returns ErrNotFound if no such note is in trash and ErrConflict if
+// a note with same name exists.
+func (api *API) Restore(name string) (*Note, error) {
+	name = strings.TrimSpace(name)
+
+	var restored *Note
+	err := api.apply("restore", []string{name}, func() error {
+		if _, found := api.idx[name]; found {
+			return fmt.Errorf("%w: note with name '%s' already exists", ErrConflict, name)
+		}
+
+		trashed, err := api.Trash()
+		if err != nil {
+			return err
+		}
+
+		for _, t := range trashed {
+			if t.Name != name {
+				continue
+			}
+
+			d, err := api.store.Get(t.key)
+			if err != nil {
+				return err
+			}
+
+			n, err := Parse(d)
+			if err != nil {
+				return err
+			}
+			n.Name = name
+
+			if err := api.store.Put(noteKey(name), d); err != nil {
+				return err
+			} else if err := api.putNode(name, newIndexNode(*n)); err != nil {
+				return err
+			} else if err := api.syncIdx(); err != nil {
+				return err
+			} else if err := api.store.Del(t.key); err != nil {
+				return err
+			}
+			restored = n
+			return nil
+		}
+
+		return fmt.Errorf("%w: note with name '%s' in trash", ErrNotFound, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// Purge permanently removes all notes deleted before the given time from the
+// trash and returns the number of notes removed.
+func (api *API) Purge(before time.Time) (int, error) {
+	count := 0
+	err := api.apply("purge", nil, func() error {
+		trashed, err := api.Trash()
+		if err != nil {
+			return err
+		}
+
+		for _, t := range trashed {
+			if !t.DeletedAt.Before(before) {
+				continue
+			}
+
+			if err := api.store.Del(t.key); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (api *API) moveToTrash(name string) error {
+	d, err := api.store.Get(noteKey(name))
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s%d/%s", trashDir, time.Now().UnixNano(), noteKey(name))
+	if err := api.store.Put(key, d); err != nil {
+		return err
+	}
+	return api.store.Del(noteKey(name))
+}
+
+// undoTrash removes the entries of the named notes moved to the trash since
+// the given time if the notes still exist.
+func (api *API) undoTrash(names []string, since time.Time) error {
+	trashed, err := api.Trash()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range trashed {
+		if _, found := api.idx[t.Name]; !found || t.DeletedAt.Before(since) || !containsString(names, t.Name) {
+			continue
+		}
+
+		api.log("warn", "removing trash entry '%s' of note that was not deleted", t.key)
+		if err := api.store.Del(t.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTrashKey parses keys of the form '.trash/<deleted-at-nanos>/<name>.md'.
+func parseTrashKey(key string) (Trashed, bool) {
+	rest := strings.TrimPrefix(key, trashDir)
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || !strings.HasSuffix(parts[1], ".md") {
+		return Trashed{}, false
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Trashed{}, false
+	}
+
+	return Trashed{
+		Name:      strings.TrimSuffix(parts[1], ".md"),
+		DeletedAt: time.Unix(0, nanos),
+		key:       key,
+	}, true
+}
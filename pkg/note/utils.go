@@ -46,6 +46,28 @@ func ParseTime(ds string) (time.Time, error) {
 	return t, nil
 }
 
+// ParseDuration parses a duration string. In addition to the units supported
+// by time.ParseDuration, 'd' (days) and 'w' (weeks) can be used as a suffix
+// for integer values (e.g., '7d', '2w').
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+
+		n, err := strconv.ParseInt(strings.TrimSuffix(s, suffix), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
 func splitTag(tag string) (k, v string) {
 	pair := strings.SplitN(tag, ":", 2)
 	key := pair[0]
@@ -62,3 +84,21 @@ func arrToSet(arr []string) map[string]struct{} {
 	}
 	return m
 }
+
+// forEachLine calls fn with the 0-based index of every line of the content
+// that is not in a fenced code block and returns the content with the lines
+// replaced by the return values of fn. Fence lines are not passed to fn.
+func forEachLine(content string, fn func(i int, line string) string) string {
+	lines := strings.Split(content, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		} else if inFence {
+			continue
+		}
+		lines[i] = fn(i, line)
+	}
+	return strings.Join(lines, "\n")
+}
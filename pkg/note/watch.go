@@ -0,0 +1,127 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const watchDebounce = 250 * time.Millisecond
+
+// Watcher is implemented by stores that can notify about changes made to the
+// store by other means (e.g., files edited directly in an editor).
+type Watcher interface {
+	// Watch blocks until the context is cancelled and calls onChange with the
+	// key of every item that is created, modified, renamed or removed.
+	Watch(ctx context.Context, onChange func(key string)) error
+}
+
+// Watch keeps the index in sync with changes made to the store by other means
+// until the context is cancelled. Notes that fail to parse are logged and
+// skipped. Returns error if the store does not support watching.
+func (api *API) Watch(ctx context.Context) error {
+	w, ok := api.store.(Watcher)
+	if !ok {
+		return fmt.Errorf("store '%s' does not support watching", api.store)
+	}
+
+	changes := make(chan string)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- w.Watch(ctx, func(key string) {
+			select {
+			case changes <- key:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	// editors usually produce a burst of events for a single save. changes
+	// are collected and applied once the burst is over.
+	pending := map[string]struct{}{}
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return <-errCh
+
+		case err := <-errCh:
+			return err
+
+		case key := <-changes:
+			if name, ok := nameFromKey(key); ok {
+				pending[name] = struct{}{}
+			} else {
+				// may be a notebook that was renamed or removed.
+				for name := range api.idx {
+					if inNotebook(name, key) {
+						pending[name] = struct{}{}
+					}
+				}
+			}
+			timer.Reset(watchDebounce)
+
+		case <-timer.C:
+			var names []string
+			for name := range pending {
+				names = append(names, name)
+			}
+			pending = map[string]struct{}{}
+
+			if err := api.refreshNotes(names); err != nil {
+				api.log("error", "failed to update index: %v", err)
+			}
+		}
+	}
+}
+
+// refreshNotes updates index entries of the named notes that were modified
+// in the store. Notes that fail to be read are skipped with a warning.
+func (api *API) refreshNotes(names []string) error {
+	unlock, err := api.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := api.loadIdx(); err != nil {
+		return err
+	}
+
+	changed := false
+	for _, name := range names {
+		entry, err := api.store.Stat(noteKey(name))
+		if errors.Is(err, ErrNotFound) {
+			if _, found := api.idx[name]; found {
+				api.log("info", "removed '%s'", name)
+				api.delNode(name)
+				changed = true
+			}
+			continue
+		} else if err != nil {
+			api.log("warn", "skipping '%s': %v", name, err)
+			continue
+		}
+
+		node, found := api.idx[name]
+		if found && node.Size == entry.Size && node.ModTime == entry.ModTime.UnixNano() {
+			continue
+		}
+
+		node, err = api.readNode(*entry)
+		if err != nil {
+			api.log("warn", "skipping '%s': %v", name, err)
+			continue
+		}
+		api.log("info", "indexed '%s'", name)
+		api.setNode(name, node)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return api.syncIdx()
+}
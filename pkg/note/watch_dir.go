@@ -0,0 +1,84 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows
+// +build darwin dragonfly freebsd linux netbsd openbsd windows
+
+package note
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch watches the directory and all its non-hidden sub-directories for
+// changes using filesystem notifications.
+func (ds *DirStore) Watch(ctx context.Context, onChange func(key string)) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	// addDir watches the directory tree and returns keys of files in it.
+	addDir := func(dir string) ([]string, error) {
+		var keys []string
+		err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			} else if !info.IsDir() {
+				rel, err := filepath.Rel(ds.dir, path)
+				if err == nil {
+					keys = append(keys, filepath.ToSlash(rel))
+				}
+				return nil
+			} else if path != ds.dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return fw.Add(path)
+		})
+		return keys, err
+	}
+
+	if _, err := addDir(ds.dir); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-fw.Errors:
+			return err
+
+		case ev := <-fw.Events:
+			base := filepath.Base(ev.Name)
+			if isTempFile(base) || strings.HasPrefix(base, ".") {
+				continue
+			}
+
+			rel, err := filepath.Rel(ds.dir, ev.Name)
+			if err != nil {
+				continue
+			}
+
+			if ev.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					// files may be created before the directory is watched.
+					keys, err := addDir(ev.Name)
+					if err != nil {
+						return err
+					}
+					for _, key := range keys {
+						onChange(key)
+					}
+					continue
+				}
+			}
+			onChange(filepath.ToSlash(rel))
+		}
+	}
+}
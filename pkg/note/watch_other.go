@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package note
+
+import (
+	"context"
+	"errors"
+)
+
+// Watch is not supported on this platform, it always returns an error.
+func (ds *DirStore) Watch(_ context.Context, _ func(key string)) error {
+	return errors.New("watching for changes is not supported on this platform")
+}
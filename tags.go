@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdTags() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tags [command]",
+		Short:   "List and manage tags across notes",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"tag"},
+	}
+
+	var asTree bool
+	cmd.Flags().BoolVarP(&asTree, "tree", "t", false, "Show tags as a hierarchy ('/' separated)")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if asTree {
+			root := notes.TagTree()
+			writeOut(cmd, root, func(_ string) string {
+				var sb strings.Builder
+				sb.WriteString(fmt.Sprintf("🏷  %s (%d)\n", root.Name, root.Count))
+				renderTagTree(&sb, root, "")
+				return strings.TrimSpace(sb.String())
+			})
+			return
+		}
+
+		tags := notes.Tags()
+		writeOut(cmd, tags, func(_ string) string {
+			if len(tags) == 0 {
+				return "❕ No tags found."
+			}
+
+			res := strings.Builder{}
+			table := tablewriter.NewWriter(&res)
+			table.SetHeader([]string{"Tag", "Notes"})
+			for _, tc := range tags {
+				table.Append([]string{tc.Tag, fmt.Sprintf("%d", tc.Count)})
+			}
+			table.Render()
+
+			return strings.TrimSpace(res.String())
+		})
+	}
+
+	cmd.AddCommand(
+		cmdTagsRename(),
+		cmdTagsMerge(),
+		cmdTagsRemove(),
+	)
+	return cmd
+}
+
+func renderTagTree(sb *strings.Builder, tn *note.TagNode, indent string) {
+	for i, c := range tn.Tags {
+		branch, next := "├── ", "│   "
+		if i == len(tn.Tags)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		sb.WriteString(fmt.Sprintf("%s%s%s (%d)\n", indent, branch, c.Name, c.Count))
+		renderTagTree(sb, c, indent+next)
+	}
+}
+
+func cmdTagsRename() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rename <old> <new>",
+		Short:   "Rename a tag in all notes",
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"mv"},
+		Run: func(cmd *cobra.Command, args []string) {
+			count, err := notes.RenameTag(args[0], args[1])
+			if err != nil {
+				exitErr("❗️ Rename failed: %v", err)
+			}
+			exitOk("✅ Renamed '%s' to '%s' in %d note(s)", args[0], args[1], count)
+		},
+	}
+}
+
+func cmdTagsMerge() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge <tag>... --into <tag>",
+		Short: "Replace one or more tags with a single tag in all notes",
+		Args:  cobra.MinimumNArgs(1),
+	}
+
+	var into string
+	cmd.Flags().StringVar(&into, "into", "", "Tag to merge into")
+	_ = cmd.MarkFlagRequired("into")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		count, err := notes.MergeTags(args, into)
+		if err != nil {
+			exitErr("❗️ Merge failed: %v", err)
+		}
+		exitOk("✅ Merged %s into '%s' in %d note(s)", strings.Join(args, ", "), into, count)
+	}
+	return cmd
+}
+
+func cmdTagsRemove() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm <tag>",
+		Short:   "Remove a tag from all notes",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"del", "delete"},
+	}
+
+	var autoConfirm bool
+	cmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "Do not ask confirmation")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		tag := strings.TrimSpace(args[0])
+
+		confirmed := autoConfirm || confirm("⚠️ You are about to remove tag '%s' from all notes, continue? [y/N]: ", tag)
+		if !confirmed {
+			exitOk("❕ Aborted removal.")
+		}
+
+		count, err := notes.DeleteTag(tag)
+		if err != nil {
+			exitErr("❗️ Remove failed: %v", err)
+		}
+		exitOk("✅ Removed '%s' from %d note(s)", tag, count)
+	}
+	return cmd
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdTasks() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks [command]",
+		Short:   "List checklist items ('- [ ] ...') across notes",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"task", "todo"},
+	}
+
+	var q note.TaskQuery
+	var query, dueAfter, dueBefore string
+	var all bool
+	flags := cmd.Flags()
+	flags.StringVar(&q.State, "state", note.TaskOpen, "Only tasks in this state (open or done)")
+	flags.BoolVar(&all, "all", false, "List tasks in any state")
+	flags.StringVarP(&q.Tag, "tag", "t", "", "Only tasks with this #tag")
+	flags.StringVar(&q.Person, "person", "", "Only tasks mentioning this @person")
+	flags.StringVarP(&q.Notes.Notebook, "notebook", "n", "", "Only tasks in notes in this notebook")
+	flags.StringVarP(&query, "query", "q", "", "Only tasks in notes matching the query (see search)")
+	flags.StringVar(&dueAfter, "due-after", "", "Due on or after this day (e.g., today, -7, 02/01/2006)")
+	flags.StringVar(&dueBefore, "due-before", "", "Due on or before this day (e.g., tom, +7)")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if all {
+			q.State = ""
+		}
+		q.Notes.Expr = parseQuery(query)
+		if dueAfter != "" {
+			q.DueRange[0] = parseDay(dueAfter).Unix()
+		}
+		if dueBefore != "" {
+			q.DueRange[1] = parseDay(dueBefore).Unix()
+		}
+
+		tasks, err := notes.Tasks(q)
+		if err != nil {
+			exitErr("❗️ Listing tasks failed: %v", err)
+		}
+
+		writeOut(cmd, tasks, func(_ string) string {
+			if len(tasks) == 0 {
+				return "❕ No tasks matched."
+			}
+
+			res := strings.Builder{}
+			table := tablewriter.NewWriter(&res)
+			table.SetHeader([]string{"Task", "Done", "Text", "Due"})
+			for _, t := range tasks {
+				done := "☐"
+				if t.Done {
+					done = "☑"
+				}
+				due := "-"
+				if t.Due != nil {
+					due = t.Due.Format("2006-01-02")
+				}
+				table.Append([]string{fmt.Sprintf("%s:%d", t.Note, t.Line), done, t.Text, due})
+			}
+			table.Render()
+
+			return strings.TrimSpace(res.String())
+		})
+	}
+
+	cmd.AddCommand(cmdTasksDone())
+	return cmd
+}
+
+func cmdTasksDone() *cobra.Command {
+	return &cobra.Command{
+		Use:     "done <note>:<line>",
+		Short:   "Toggle the checkbox of a task",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"toggle", "undo"},
+		Run: func(cmd *cobra.Command, args []string) {
+			// note names may contain ':' (e.g., day notes).
+			idx := strings.LastIndex(args[0], ":")
+			if idx <= 0 {
+				exitErr("❓ Task must be specified as <note>:<line>, not '%s'", args[0])
+			}
+			line, err := strconv.Atoi(args[0][idx+1:])
+			if err != nil {
+				exitErr("❓ Invalid line number in '%s'", args[0])
+			}
+			name := inferName([]string{args[0][:idx]})[0]
+
+			task, err := notes.ToggleTask(name, line)
+			if err != nil {
+				exitErr("❗️ Failed to update task: %v", err)
+			}
+
+			writeOut(cmd, task, func(_ string) string {
+				if task.Done {
+					return fmt.Sprintf("☑ Done: %s", task.Text)
+				}
+				return fmt.Sprintf("☐ Re-opened: %s", task.Text)
+			})
+		},
+	}
+}
+
+// parseDay returns the start of the (local) day specified by the time-string.
+func parseDay(spec string) time.Time {
+	t, err := note.ParseTime(strings.TrimSpace(spec))
+	if err != nil {
+		exitErr("❓ Sorry, '%s' is not a valid day: %v", spec, err)
+	}
+	return note.PeriodStart(note.PeriodDay, t)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+const sampleTemplate = `---
+tags: []
+---
+
+# {{ .Title }}
+
+Created on {{ date "2006-01-02" .Date }}.
+{{ with .PrevDay }}Previous day: [[{{ . }}]]{{ end }}
+`
+
+func cmdTemplates() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "templates [command]",
+		Short:   "List and edit templates for new notes",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"tmpl"},
+		Run: func(cmd *cobra.Command, args []string) {
+			names, err := notes.Templates()
+			if err != nil {
+				exitErr("❗️ Failed to list templates: %v", err)
+			}
+
+			writeOut(cmd, names, func(_ string) string {
+				if len(names) == 0 {
+					return "❕ No templates. Create one with 'connote templates edit <name>'."
+				}
+				return "📄 " + strings.Join(names, "\n📄 ")
+			})
+		},
+	}
+
+	cmd.AddCommand(cmdTemplatesEdit())
+	return cmd
+}
+
+func cmdTemplatesEdit() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Create/Edit a template",
+		Long: "Create/Edit a template. Templates are notes with Go text/template actions. Available\n" +
+			"fields are .Name, .Title, .Profile, .Date and .PrevDay (latest day note before .Date),\n" +
+			"and functions are now, date <layout> <time>, addDays <time> <n>, dayNote <time> and\n" +
+			"periodNote <kind> <time> (kind is day, week, month, quarter or year).",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.TrimSpace(args[0])
+
+			text, err := notes.Template(name)
+			if errors.Is(err, note.ErrNotFound) {
+				text = sampleTemplate
+			} else if err != nil {
+				exitErr("❗️ %v", err)
+			}
+
+			edited, err := externalEditor([]byte(text))
+			if err != nil {
+				exitErr("❗️ failed to open editor: %v", err)
+			} else if err := notes.PutTemplate(name, string(edited)); err != nil {
+				exitErr("❗️ failed to save template: %v", err)
+			}
+			exitOk("✅ Template '%s' saved", name)
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdTrash() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "trash <command>",
+		Short:   "Manage deleted notes",
+		Aliases: []string{"bin"},
+	}
+
+	cmd.AddCommand(
+		cmdTrashList(),
+		cmdTrashRestore(),
+		cmdTrashPurge(),
+	)
+	return cmd
+}
+
+func cmdTrashList() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Short:   "List notes in trash",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"list"},
+		Run: func(cmd *cobra.Command, args []string) {
+			trashed, err := notes.Trash()
+			if err != nil {
+				exitErr("❗️ Failed to list trash: %v", err)
+			}
+
+			writeOut(cmd, trashed, func(_ string) string {
+				if len(trashed) == 0 {
+					return "❕ Trash is empty."
+				}
+
+				res := strings.Builder{}
+				table := tablewriter.NewWriter(&res)
+				table.SetHeader([]string{"Name", "Deleted On"})
+				for _, t := range trashed {
+					table.Append([]string{t.Name, t.DeletedAt.Format("2006-01-02 15:04")})
+				}
+				table.Render()
+
+				return strings.TrimSpace(res.String())
+			})
+		},
+	}
+}
+
+func cmdTrashRestore() *cobra.Command {
+	return &cobra.Command{
+		Use:     "restore <name>",
+		Short:   "Restore a deleted note by name",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"undo", "undelete"},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.TrimSpace(args[0])
+
+			if _, err := notes.Restore(name); err != nil {
+				exitErr("❗️ Restore failed: %v", err)
+			}
+			exitOk("✅ Note '%s' has been restored", name)
+		},
+	}
+}
+
+func cmdTrashPurge() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "purge",
+		Short:   "Permanently delete notes from trash",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"empty"},
+	}
+
+	var olderThan string
+	var autoConfirm bool
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Only purge notes deleted before this duration (e.g., 30d, 2w, 12h)")
+	cmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "Do not ask confirmation")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		before := time.Now()
+		if olderThan = strings.TrimSpace(olderThan); olderThan != "" {
+			d, err := note.ParseDuration(olderThan)
+			if err != nil {
+				exitErr("❓ Sorry, '%s' is not a valid duration: %v", olderThan, err)
+			}
+			before = before.Add(-d)
+		}
+
+		confirmed := autoConfirm || confirm("⚠️ You are about to permanently delete notes from trash, continue? [y/N]: ")
+		if !confirmed {
+			exitOk("❕ Aborted purge.")
+		}
+
+		count, err := notes.Purge(before)
+		if err != nil {
+			exitErr("❗️ Purge failed: %v", err)
+		}
+		exitOk("✅ Purged %d note(s) from trash", count)
+	}
+	return cmd
+}
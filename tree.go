@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spy16/connote/pkg/note"
+)
+
+func cmdTree() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tree [notebook]",
+		Short:   "Show notebooks as a tree with note counts",
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"notebooks", "nb"},
+	}
+
+	var showNotes bool
+	cmd.Flags().BoolVarP(&showNotes, "notes", "n", false, "Show notes along with notebooks")
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		var path string
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		root, err := notes.Tree(path)
+		if err != nil {
+			exitErr("❗️ %s", err)
+		}
+
+		writeOut(cmd, root, func(_ string) string {
+			var sb strings.Builder
+			sb.WriteString(fmt.Sprintf("📂 %s (%d)\n", root.Name, root.Count))
+			renderTree(&sb, root, "", showNotes)
+			return strings.TrimSpace(sb.String())
+		})
+	}
+	return cmd
+}
+
+func renderTree(sb *strings.Builder, nb *note.Notebook, indent string, showNotes bool) {
+	type item struct {
+		label string
+		child *note.Notebook
+	}
+
+	var items []item
+	for _, c := range nb.Notebooks {
+		items = append(items, item{label: fmt.Sprintf("📂 %s (%d)", c.Name, c.Count), child: c})
+	}
+	if showNotes {
+		for _, name := range nb.Notes {
+			items = append(items, item{label: name[strings.LastIndex(name, "/")+1:]})
+		}
+	}
+
+	for i, it := range items {
+		branch, next := "├── ", "│   "
+		if i == len(items)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		sb.WriteString(indent + branch + it.label + "\n")
+		if it.child != nil {
+			renderTree(sb, it.child, indent+next, showNotes)
+		}
+	}
+}
@@ -3,12 +3,10 @@ package main
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
-	"time"
 
 	"github.com/spy16/connote/pkg/note"
 )
@@ -51,7 +49,7 @@ func externalEditor(d []byte) ([]byte, error) {
 	return ioutil.ReadAll(f)
 }
 
-func fzfSearch(notes []note.Note) (string, error) {
+func fzfSearch(notes []note.Hit) (string, error) {
 	items := make([]string, len(notes), len(notes))
 	for i := range notes {
 		items[i] = notes[i].Name
@@ -75,7 +73,3 @@ func fzfSearch(notes []note.Note) (string, error) {
 	sel := strings.TrimSpace(buf.String())
 	return sel, nil
 }
-
-func makeDayID(t time.Time) string {
-	return fmt.Sprintf("day:%d-%s-%d", t.Day(), t.Month().String()[0:3], t.Year())
-}